@@ -16,8 +16,10 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
-	"time"
+	"strings"
+	"text/tabwriter"
 
 	"gsmate/config"
 	"gsmate/internal/logger"
@@ -26,22 +28,22 @@ import (
 	"gsmate/pkg/version"
 
 	"github.com/urfave/cli/v2"
+	"github.com/vimiix/pkg/file"
+	"golang.org/x/term"
 )
 
-var (
-	authors = []*cli.Author{
-		{Name: "Vimiix", Email: "i@vimiix.com"},
-	}
-	copyright = func() string {
-		yearRange := "2024"
-		nowYear := time.Now().Year()
-		if nowYear > 2024 {
-			yearRange = fmt.Sprintf("2024-%d", nowYear)
-		}
-		return fmt.Sprintf("Copyright (C) %s Vimiix", yearRange)
-	}
+const (
+	// exitFailure is the exit code of a failed run.
+	exitFailure = 1
+	// exitScriptError is the exit code of a batch run stopped by
+	// on_error_stop, as psql does.
+	exitScriptError = 3
 )
 
+var authors = []*cli.Author{
+	{Name: "Vimiix", Email: "i@vimiix.com"},
+}
+
 func main() {
 	connArgs := &config.Connection{}
 	app := cli.NewApp()
@@ -50,7 +52,7 @@ func main() {
 	app.Version = version.Version
 	app.HideVersion = true // self control version flag to ensure help massage style is consistent
 	app.Authors = authors
-	app.Copyright = copyright()
+	app.Copyright = version.Copyright()
 	app.EnableBashCompletion = true
 	app.UseShortOptionHandling = true
 	app.HideHelp = true
@@ -64,7 +66,7 @@ func main() {
 		},
 		&cli.BoolFlag{
 			Name:               "version",
-			Aliases:            []string{"v"},
+			Aliases:            []string{"V"},
 			Usage:              "Print the version",
 			DisableDefaultText: true,
 		},
@@ -74,13 +76,11 @@ func main() {
 			EnvVars:     []string{"PGHOST"},
 			Destination: &connArgs.Host,
 			Usage:       "Database server host or socket directory",
-			Required:    true,
 		},
 		&cli.IntFlag{
 			Name:        "port",
 			Aliases:     []string{"p"},
 			EnvVars:     []string{"PGPORT"},
-			Value:       5432,
 			Destination: &connArgs.Port,
 			Usage:       "Database server port",
 			Action: func(ctx *cli.Context, v int) error {
@@ -96,27 +96,29 @@ func main() {
 			EnvVars:     []string{"PGUSER"},
 			Destination: &connArgs.Username,
 			Usage:       "Database username",
-			Required:    true,
 		},
-		&cli.StringFlag{
-			Name:        "password",
-			Aliases:     []string{"W"},
-			EnvVars:     []string{"PGPASSWORD"},
-			Destination: &connArgs.Password,
-			Usage:       "Connection password",
+		&cli.BoolFlag{
+			Name:               "password",
+			Aliases:            []string{"W"},
+			Usage:              "Force password prompt",
+			DisableDefaultText: true,
+		},
+		&cli.BoolFlag{
+			Name:               "no-password",
+			Aliases:            []string{"w"},
+			Usage:              "Never prompt for password",
+			DisableDefaultText: true,
 		},
 		&cli.StringFlag{
 			Name:        "dbname",
 			Aliases:     []string{"d"},
 			EnvVars:     []string{"PGDATABASE"},
-			Value:       "postgres",
 			Destination: &connArgs.DBName,
-			Usage:       "Database name to connect to",
+			Usage:       "Database name, connection URI or \"key=value\" conninfo string to connect to",
 		},
 		&cli.StringFlag{
 			Name:        "appname",
 			EnvVars:     []string{"PGAPPNAME"},
-			Value:       "gsmate",
 			Destination: &connArgs.AppName,
 			Usage:       "Custom application name",
 		},
@@ -124,9 +126,86 @@ func main() {
 			Name:        "timeout",
 			EnvVars:     []string{"PGCONNECT_TIMEOUT"},
 			Destination: &connArgs.ConnTimeout,
-			Value:       time.Second * 10,
 			Usage:       "Connection timeout",
 		},
+		&cli.StringFlag{
+			Name:        "service",
+			EnvVars:     []string{"PGSERVICE"},
+			Destination: &connArgs.Service,
+			Usage:       "Connection service name of pg_service.conf",
+		},
+		&cli.StringFlag{
+			Name:    "profile",
+			Aliases: []string{"P"},
+			EnvVars: []string{"GSMATE_PROFILE"},
+			Usage:   "Connection profile of the config file to use",
+		},
+		&cli.StringFlag{
+			Name:        "sslmode",
+			EnvVars:     []string{"PGSSLMODE"},
+			Destination: &connArgs.SSLMode,
+			Usage:       "SSL mode (disable, allow, prefer, require, verify-ca, verify-full)",
+			Action: func(ctx *cli.Context, v string) error {
+				return config.CheckSSLMode(v)
+			},
+		},
+		&cli.StringFlag{
+			Name:        "sslcert",
+			EnvVars:     []string{"PGSSLCERT"},
+			Destination: &connArgs.SSLCert,
+			Usage:       "Client SSL certificate file",
+		},
+		&cli.StringFlag{
+			Name:        "sslkey",
+			EnvVars:     []string{"PGSSLKEY"},
+			Destination: &connArgs.SSLKey,
+			Usage:       "Client SSL private key file",
+		},
+		&cli.StringFlag{
+			Name:        "sslrootcert",
+			EnvVars:     []string{"PGSSLROOTCERT"},
+			Destination: &connArgs.SSLRootCert,
+			Usage:       "SSL root certificate file to verify the server with",
+		},
+		&cli.StringFlag{
+			Name:        "sslcrl",
+			EnvVars:     []string{"PGSSLCRL"},
+			Destination: &connArgs.SSLCRL,
+			Usage:       "SSL certificate revocation list file",
+		},
+		&cli.StringFlag{
+			Name:        "sslenccert",
+			Destination: &connArgs.SSLEncCert,
+			Usage:       "Client SM2 encryption certificate file for TLCP connections",
+		},
+		&cli.StringFlag{
+			Name:        "sslenckey",
+			Destination: &connArgs.SSLEncKey,
+			Usage:       "Client SM2 encryption private key file for TLCP connections",
+		},
+		&cli.StringSliceFlag{
+			Name:    "command",
+			Aliases: []string{"c"},
+			Usage:   "Run only single command (SQL or internal) and exit, can be repeated",
+		},
+		&cli.StringFlag{
+			Name:    "file",
+			Aliases: []string{"f"},
+			Usage:   "Execute commands from file (- for stdin), then exit",
+		},
+		&cli.StringSliceFlag{
+			Name:    "set",
+			Aliases: []string{"v", "variable"},
+			Usage:   "Set variable NAME to VALUE (NAME=VALUE), as \\set does, can be repeated",
+		},
+	}
+
+	app.Commands = []*cli.Command{
+		{
+			Name:   "profiles",
+			Usage:  "List the connection profiles of the config file",
+			Action: listProfiles,
+		},
 	}
 
 	app.Action = func(c *cli.Context) error {
@@ -143,22 +222,155 @@ func main() {
 		}
 
 		cfg := config.Get()
+		if profile := c.String("profile"); profile != "" {
+			if err := cfg.ApplyProfile(profile); err != nil {
+				return err
+			}
+		}
+		// like libpq, a conninfo string or URI given as dbname takes
+		// precedence over the separate connection flags
+		if config.IsConnString(connArgs.DBName) {
+			parsed, err := config.ParseConnString(connArgs.DBName)
+			if err != nil {
+				return err
+			}
+			connArgs.DBName = ""
+			connArgs.Merge(parsed)
+		}
+		service := connArgs.Service
+		if service == "" {
+			service = cfg.Service
+		}
+		if service != "" {
+			if err := cfg.Connection.MergeService(service); err != nil {
+				return err
+			}
+		}
+		if password, ok := utils.Getenv("PGPASSWORD"); ok && connArgs.Password == "" {
+			connArgs.Password = password
+		}
 		cfg.Connection.Merge(connArgs)
+		if err := config.CheckSSLMode(cfg.SSLMode); err != nil {
+			return err
+		}
+		if cfg.TargetSessionAttrs != "" {
+			if err := config.CheckTargetSessionAttrs(cfg.TargetSessionAttrs); err != nil {
+				return err
+			}
+		}
+		if err := config.CheckOnErrorRollback(cfg.OnErrorRollback); err != nil {
+			return err
+		}
+		if err := config.CheckVerbosity(cfg.Verbosity); err != nil {
+			return err
+		}
+		cfg.NoPasswordPrompt = c.Bool("no-password")
+		if c.Bool("password") {
+			password, err := utils.ReadPassword(fmt.Sprintf("Password for user %s: ", cfg.Username))
+			if err != nil {
+				return err
+			}
+			cfg.Password = password
+		}
 
 		if cfg.Silence {
 			logger.MuteLogger()
 		} else {
 			logger.SetLogLevelByString(cfg.LogLevel)
 		}
+		if cfg.LogFile != "" {
+			if err := logger.SetLogFile(file.ExpandHomePath(cfg.LogFile)); err != nil {
+				return fmt.Errorf("open log file: %w", err)
+			}
+		}
 
 		dbcli, err := client.New(cfg)
 		if err != nil {
 			return err
 		}
+		for _, v := range c.StringSlice("set") {
+			name, value, ok := strings.Cut(v, "=")
+			if !ok {
+				err = dbcli.UnsetVar(name)
+			} else {
+				err = dbcli.SetVar(name, value)
+			}
+			if err != nil {
+				return err
+			}
+		}
 
-		return dbcli.Run()
+		var sources []func() (io.Reader, error)
+		for _, command := range c.StringSlice("command") {
+			sources = append(sources, func() (io.Reader, error) {
+				return strings.NewReader(command), nil
+			})
+		}
+		if file := c.String("file"); file != "" {
+			sources = append(sources, func() (io.Reader, error) {
+				if file == "-" {
+					return os.Stdin, nil
+				}
+				return os.Open(file)
+			})
+		}
+		if len(sources) == 0 {
+			if term.IsTerminal(int(os.Stdin.Fd())) {
+				return dbcli.Run()
+			}
+			sources = append(sources, func() (io.Reader, error) {
+				return os.Stdin, nil
+			})
+		}
+		return runBatch(dbcli, cfg, sources)
 	}
 	if err := app.Run(os.Args); err != nil {
 		utils.PrintError(err)
+		os.Exit(exitFailure)
+	}
+}
+
+// runBatch executes every source in order. Errors of the executed statements
+// are already reported by the client, so a failed run only sets the exit
+// code.
+func runBatch(dbcli *client.DBClient, cfg *config.Config, sources []func() (io.Reader, error)) error {
+	failed := false
+	for _, source := range sources {
+		r, err := source()
+		if err != nil {
+			return err
+		}
+		err = dbcli.RunBatch(r)
+		if c, ok := r.(io.Closer); ok && r != os.Stdin {
+			_ = c.Close()
+		}
+		if err != nil {
+			if cfg.OnErrorStop {
+				return cli.Exit("", exitScriptError)
+			}
+			failed = true
+		}
+	}
+	if failed {
+		return cli.Exit("", exitFailure)
+	}
+	return nil
+}
+
+// listProfiles prints the connection profiles of the config file.
+func listProfiles(*cli.Context) error {
+	if err := config.Init(); err != nil {
+		return err
+	}
+	profiles := config.Get().Profiles()
+	if len(profiles) == 0 {
+		fmt.Printf("No connection profiles found in %s\n", config.ConfigFile())
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tADDRESS")
+	for _, p := range profiles {
+		fmt.Fprintf(w, "%s\t%s\n", p.Name, p.Connection.Address())
 	}
+	return w.Flush()
 }
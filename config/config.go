@@ -22,6 +22,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
 	"time"
 
 	"gsmate/internal/utils"
@@ -61,18 +62,32 @@ type Config struct {
 	SyntaxHighlightStyle string `ini:"syntax_highlight_style,omitempty"`
 	OnErrorStop          bool   `ini:"on_error_stop,omitempty"`
 	UsePager             bool   `ini:"use_pager,omitempty"`
+	Autocommit           bool   `ini:"autocommit,omitempty"`
+	OnErrorRollback      string `ini:"on_error_rollback,omitempty"`
+	Verbosity            string `ini:"verbosity,omitempty"`
+	Timing               bool   `ini:"timing,omitempty"`
+	LogFile              string `ini:"log_file,omitempty"`
 
 	// auto detected fields
 	Pager                 string `ini:"-"`
 	Editor                string `ini:"-"`
 	SyntaxHighlightFormat string `ini:"-"`
-	SSLMode               string `ini:"-"`
+	// NoPasswordPrompt disables prompting for a password, set by -w
+	NoPasswordPrompt bool `ini:"-"`
 
 	Connection `ini:"connection"`
+
+	// profiles are the [connection.<name>] sections
+	profiles []*Profile `ini:"-"`
+	// printOptions are the preferred print settings of the [print] section
+	printOptions map[string]string `ini:"-"`
 }
 
 func GetConfigMap() map[string]string {
 	c := defaultConfig
+	if c == nil {
+		return nil
+	}
 	return map[string]string{
 		"prompt":                 c.Prompt,
 		"less_chatty":            strconv.FormatBool(c.LessChatty),
@@ -82,7 +97,42 @@ func GetConfigMap() map[string]string {
 		"syntax_highlight":       strconv.FormatBool(c.SyntaxHighlight),
 		"syntax_highlight_style": c.SyntaxHighlightStyle,
 		"on_error_stop":          strconv.FormatBool(c.OnErrorStop),
+		"use_pager":              strconv.FormatBool(c.UsePager),
+		"autocommit":             strconv.FormatBool(c.Autocommit),
+		"on_error_rollback":      c.OnErrorRollback,
+		"verbosity":              c.Verbosity,
+		"timing":                 strconv.FormatBool(c.Timing),
+		"log_file":               c.LogFile,
+	}
+}
+
+// OnErrorRollbackModes are the supported values of on_error_rollback.
+var OnErrorRollbackModes = []string{"off", "on", "interactive"}
+
+// CheckOnErrorRollback returns an error when mode is not one of
+// OnErrorRollbackModes.
+func CheckOnErrorRollback(mode string) error {
+	for _, m := range OnErrorRollbackModes {
+		if mode == m {
+			return nil
+		}
 	}
+	return errors.Errorf("invalid on_error_rollback %q, must be one of: %s",
+		mode, strings.Join(OnErrorRollbackModes, ", "))
+}
+
+// Verbosities are the supported values of verbosity.
+var Verbosities = []string{"default", "verbose", "terse"}
+
+// CheckVerbosity returns an error when verbosity is not one of Verbosities.
+func CheckVerbosity(verbosity string) error {
+	for _, v := range Verbosities {
+		if verbosity == v {
+			return nil
+		}
+	}
+	return errors.Errorf("invalid verbosity %q, must be one of: %s",
+		verbosity, strings.Join(Verbosities, ", "))
 }
 
 func (c *Config) PromptPrefix() string {
@@ -122,51 +172,51 @@ func (c *Config) PromptPrefix() string {
 }
 
 func Init() error {
-	defaultConfig = newDefault()
-	cfgFile := filepath.Join(DefaultLocation(), "config")
-	if err := writeDefaultConfig(cfgFile, false); err != nil {
+	cfg, err := Load()
+	if err != nil {
 		return err
 	}
-	if err := ini.MapTo(defaultConfig, cfgFile); err != nil {
-		return errors.Wrapf(err, "load config: %s", cfgFile)
-	}
+	defaultConfig = cfg
 
 	locale := "en-US"
 	if s, err := syslocale.GetLocale(); err == nil {
 		locale = s
 	}
-	usePager := "on"
-	if !defaultConfig.UsePager {
-		usePager = "off"
+	printConfig = newPrintConfig(cfg, locale)
+	for name, v := range cfg.printOptions {
+		printConfig[name] = v
 	}
+	return nil
+}
 
-	printConfig = map[string]string{
-		"border":                   "1",
-		"columns":                  "0",
-		"csv_fieldsep":             ",",
-		"expanded":                 "off",
-		"fieldsep":                 "|",
-		"fieldsep_zero":            "off",
-		"footer":                   "on",
-		"format":                   "aligned",
-		"linestyle":                "ascii",
-		"locale":                   locale,
-		"null":                     "",
-		"numericlocale":            "off",
-		"pager":                    usePager,
-		"pager_cmd":                defaultConfig.Pager,
-		"recordsep":                "\n",
-		"recordsep_zero":           "off",
-		"tableattr":                "",
-		"time":                     "RFC3339Nano",
-		"timezone":                 "",
-		"title":                    "",
-		"tuples_only":              "off",
-		"unicode_border_linestyle": "single",
-		"unicode_column_linestyle": "single",
-		"unicode_header_linestyle": "single",
+// ConfigFile returns the path of the config file.
+func ConfigFile() string {
+	return filepath.Join(DefaultLocation(), "config")
+}
+
+// Load reads the config file into a new Config, writing the default config
+// file first when missing. Unlike Init, it leaves the print settings alone,
+// only reading the preferred ones of the [print] section.
+func Load() (*Config, error) {
+	cfg := newDefault()
+	cfgFile := ConfigFile()
+	if err := writeDefaultConfig(cfgFile, false); err != nil {
+		return nil, err
 	}
-	return nil
+	f, err := ini.Load(cfgFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "load config: %s", cfgFile)
+	}
+	if err := f.MapTo(cfg); err != nil {
+		return nil, errors.Wrapf(err, "load config: %s", cfgFile)
+	}
+	if cfg.profiles, err = loadProfiles(f); err != nil {
+		return nil, errors.Wrapf(err, "load config: %s", cfgFile)
+	}
+	if cfg.printOptions, err = loadPrintOptions(f); err != nil {
+		return nil, errors.Wrapf(err, "load config: %s", cfgFile)
+	}
+	return cfg, nil
 }
 
 func newDefault() *Config {
@@ -179,7 +229,8 @@ func newDefault() *Config {
 	if noColor || colorLevel < terminfo.ColorLevelBasic {
 		enableHighlight = false
 	}
-	pagerCmd, ok := utils.Getenv("PAGER")
+	// an empty variable disables the pager
+	pagerCmd, ok := utils.Getenv("GSMATE_PAGER", "PSQL_PAGER", "PAGER")
 	if !ok {
 		for _, s := range []string{"less", "more"} {
 			if _, err := exec.LookPath(s); err == nil {
@@ -190,9 +241,9 @@ func newDefault() *Config {
 	}
 
 	editorCmd, _ := utils.Getenv("EDITOR", "VISUAL")
-	sslmode, ok := utils.Getenv("SSLMODE")
+	sslmode, ok := utils.Getenv("PGSSLMODE", "SSLMODE")
 	if !ok {
-		sslmode = "retry"
+		sslmode = "prefer"
 	}
 	return &Config{
 		Prompt:                defaultPrompt,
@@ -201,17 +252,21 @@ func newDefault() *Config {
 		SyntaxHighlight:       enableHighlight,
 		SyntaxHighlightStyle:  "monokai",
 		SyntaxHighlightFormat: colorLevel.ChromaFormatterName(),
+		Autocommit:            true,
+		OnErrorRollback:       "off",
+		Verbosity:             "default",
 
-		Pager:   pagerCmd,
-		Editor:  editorCmd,
-		SSLMode: sslmode,
+		Pager:  pagerCmd,
+		Editor: editorCmd,
 		Connection: Connection{
 			Host:         "localhost",
 			Port:         26000,
 			Username:     "omm",
 			DBName:       "postgres",
+			AppName:      "gsmate",
 			ConnTimeout:  time.Second * 10,
 			QueryTimeout: time.Second * 120,
+			SSLMode:      sslmode,
 		},
 	}
 }
@@ -16,10 +16,45 @@ package config
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/pkg/errors"
 )
 
+// TargetSessionAttrs are the supported values of the target_session_attrs
+// connection option.
+var TargetSessionAttrs = []string{"any", "read-write", "read-only", "primary", "standby", "prefer-standby"}
+
+// CheckTargetSessionAttrs returns an error when attrs is not one of
+// TargetSessionAttrs.
+func CheckTargetSessionAttrs(attrs string) error {
+	for _, a := range TargetSessionAttrs {
+		if attrs == a {
+			return nil
+		}
+	}
+	return errors.Errorf("invalid target_session_attrs %q, must be one of: %s",
+		attrs, strings.Join(TargetSessionAttrs, ", "))
+}
+
+// SSLModes are the supported values of the sslmode connection option.
+var SSLModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// CheckSSLMode returns an error when mode is not one of SSLModes.
+func CheckSSLMode(mode string) error {
+	for _, m := range SSLModes {
+		if mode == m {
+			return nil
+		}
+	}
+	return errors.Errorf("invalid sslmode %q, must be one of: %s", mode, strings.Join(SSLModes, ", "))
+}
+
 type Connection struct {
+	// Host is a host name, IP address or socket directory, or a comma
+	// separated list of them, each optionally followed by ":port".
 	Host         string        `ini:"host,omitempty"`
 	Port         int           `ini:"port,omitempty"`
 	Username     string        `ini:"user,omitempty"`
@@ -28,6 +63,78 @@ type Connection struct {
 	AppName      string        `ini:"application_name,omitempty"`
 	ConnTimeout  time.Duration `ini:"connect_timeout,omitempty"`
 	QueryTimeout time.Duration `ini:"query_timeout,omitempty"`
+
+	SSLMode     string `ini:"sslmode,omitempty"`
+	SSLCert     string `ini:"sslcert,omitempty"`
+	SSLKey      string `ini:"sslkey,omitempty"`
+	SSLRootCert string `ini:"sslrootcert,omitempty"`
+	SSLCRL      string `ini:"sslcrl,omitempty"`
+	// SSLEncCert and SSLEncKey are the SM2 encryption certificate and key
+	// of a TLCP (national cryptography) connection, SSLCert and SSLKey
+	// being the signing pair.
+	SSLEncCert string `ini:"sslenccert,omitempty"`
+	SSLEncKey  string `ini:"sslenckey,omitempty"`
+
+	// TargetSessionAttrs selects the kind of server accepted among the
+	// hosts, one of TargetSessionAttrs, empty meaning any.
+	TargetSessionAttrs string `ini:"target_session_attrs,omitempty"`
+	// Service names a pg_service.conf section holding further options.
+	Service string `ini:"service,omitempty"`
+}
+
+// Addr is the host and port of a server.
+type Addr struct {
+	Host string
+	Port int
+}
+
+// String formats a as host:port, enclosing IPv6 addresses in brackets.
+func (a Addr) String() string {
+	if strings.Contains(a.Host, ":") {
+		return fmt.Sprintf("[%s]:%d", a.Host, a.Port)
+	}
+	return fmt.Sprintf("%s:%d", a.Host, a.Port)
+}
+
+// Addrs returns the server addresses listed in Host, using Port for the
+// hosts without an explicit port.
+func (c *Connection) Addrs() []Addr {
+	var addrs []Addr
+	for _, h := range strings.Split(c.Host, ",") {
+		host, port, ok := splitHostPort(strings.TrimSpace(h))
+		if !ok {
+			port = c.Port
+		}
+		addrs = append(addrs, Addr{Host: host, Port: port})
+	}
+	return addrs
+}
+
+// splitHostPort splits "host:port" and "[ipv6]:port", reporting false when s
+// has no port.
+func splitHostPort(s string) (string, int, bool) {
+	if strings.HasPrefix(s, "[") {
+		end := strings.Index(s, "]")
+		if end == -1 {
+			return s, 0, false
+		}
+		host := s[1:end]
+		port, err := strconv.Atoi(strings.TrimPrefix(s[end+1:], ":"))
+		if err != nil {
+			return host, 0, false
+		}
+		return host, port, true
+	}
+	i := strings.LastIndex(s, ":")
+	// bare IPv6 addresses have several colons
+	if i == -1 || strings.Count(s, ":") > 1 {
+		return s, 0, false
+	}
+	port, err := strconv.Atoi(s[i+1:])
+	if err != nil {
+		return s, 0, false
+	}
+	return s[:i], port, true
 }
 
 func (c *Connection) Merge(other *Connection) {
@@ -52,6 +159,39 @@ func (c *Connection) Merge(other *Connection) {
 	if other.AppName != "" {
 		c.AppName = other.AppName
 	}
+	if other.Service != "" {
+		c.Service = other.Service
+	}
+	if other.TargetSessionAttrs != "" {
+		c.TargetSessionAttrs = other.TargetSessionAttrs
+	}
+	if other.ConnTimeout != 0 {
+		c.ConnTimeout = other.ConnTimeout
+	}
+	if other.QueryTimeout != 0 {
+		c.QueryTimeout = other.QueryTimeout
+	}
+	if other.SSLMode != "" {
+		c.SSLMode = other.SSLMode
+	}
+	if other.SSLCert != "" {
+		c.SSLCert = other.SSLCert
+	}
+	if other.SSLKey != "" {
+		c.SSLKey = other.SSLKey
+	}
+	if other.SSLRootCert != "" {
+		c.SSLRootCert = other.SSLRootCert
+	}
+	if other.SSLCRL != "" {
+		c.SSLCRL = other.SSLCRL
+	}
+	if other.SSLEncCert != "" {
+		c.SSLEncCert = other.SSLEncCert
+	}
+	if other.SSLEncKey != "" {
+		c.SSLEncKey = other.SSLEncKey
+	}
 }
 
 func (c *Connection) Tidy() {
@@ -61,18 +201,65 @@ func (c *Connection) Tidy() {
 	}
 }
 
+// Address returns the normalized "user@host:port[,host:port...]/dbname"
+// form of the connection.
 func (c *Connection) Address() string {
-	return fmt.Sprintf("%s@%s:%d/%s", c.Username, c.Host, c.Port, c.DBName)
+	addrs := c.Addrs()
+	hosts := make([]string, 0, len(addrs))
+	for _, a := range addrs {
+		hosts = append(hosts, a.String())
+	}
+	return fmt.Sprintf("%s@%s/%s", c.Username, strings.Join(hosts, ","), c.DBName)
 }
 
 // GetDSN returns the DSN string for connecting to the database server.
 func (c *Connection) GetDSN() string {
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable application_name=%s",
-		c.Host, c.Port, c.Username, c.Password, c.DBName, c.AppName)
+	sslmode := c.SSLMode
+	if sslmode == "" {
+		sslmode = "disable"
+	}
+	addrs := c.Addrs()
+	hosts := make([]string, 0, len(addrs))
+	ports := make([]string, 0, len(addrs))
+	for _, a := range addrs {
+		hosts = append(hosts, a.Host)
+		ports = append(ports, strconv.Itoa(a.Port))
+	}
+	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s application_name=%s",
+		dsnValue(strings.Join(hosts, ",")), strings.Join(ports, ","), dsnValue(c.Username),
+		dsnValue(c.Password), dsnValue(c.DBName), sslmode, dsnValue(c.AppName))
+
+	if c.TargetSessionAttrs != "" {
+		dsn += " target_session_attrs=" + dsnValue(c.TargetSessionAttrs)
+	}
 
 	if c.ConnTimeout > 0 {
 		dsn += fmt.Sprintf(" connect_timeout=%d", int(c.ConnTimeout.Seconds()))
 	}
 
+	if sslmode != "disable" {
+		for _, opt := range []struct{ key, value string }{
+			{"sslcert", c.SSLCert},
+			{"sslkey", c.SSLKey},
+			{"sslrootcert", c.SSLRootCert},
+			{"sslcrl", c.SSLCRL},
+			{"sslenccert", c.SSLEncCert},
+			{"sslenckey", c.SSLEncKey},
+		} {
+			if opt.value != "" {
+				dsn += fmt.Sprintf(" %s=%s", opt.key, dsnValue(opt.value))
+			}
+		}
+	}
+
 	return dsn
 }
+
+// dsnValue quotes s as a conninfo value when it is empty or contains
+// spaces, quotes or backslashes.
+func dsnValue(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\\") {
+		return s
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,44 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDSN(t *testing.T) {
+	t.Run("Disabled SSL", func(t *testing.T) {
+		c := &Connection{Host: "localhost", Port: 26000, Username: "omm", DBName: "postgres", AppName: "gsmate", SSLCert: "a.crt"}
+		assert.Equal(t, "host=localhost port=26000 user=omm password='' dbname=postgres sslmode=disable application_name=gsmate", c.GetDSN())
+	})
+
+	t.Run("SSL Files", func(t *testing.T) {
+		c := &Connection{
+			Host: "db", Port: 5432, Username: "omm", Password: "it's secret", DBName: "postgres", AppName: "gsmate",
+			SSLMode: "verify-full", SSLRootCert: "/etc/ssl/ca cert.pem", SSLEncCert: "enc.crt",
+		}
+		assert.Equal(t, `host=db port=5432 user=omm password='it\'s secret' dbname=postgres sslmode=verify-full application_name=gsmate`+
+			` sslrootcert='/etc/ssl/ca cert.pem' sslenccert=enc.crt`, c.GetDSN())
+	})
+}
+
+func TestCheckSSLMode(t *testing.T) {
+	for _, mode := range SSLModes {
+		assert.NoError(t, CheckSSLMode(mode))
+	}
+	assert.Error(t, CheckSSLMode("retry"))
+}
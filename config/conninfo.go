@@ -0,0 +1,252 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// uriSchemes are the accepted connection URI schemes.
+var uriSchemes = []string{"postgresql://", "postgres://"}
+
+// IsConnString reports whether s is a connection URI or a key=value
+// conninfo string rather than a plain database name, like libpq does for
+// the dbname parameter.
+func IsConnString(s string) bool {
+	for _, scheme := range uriSchemes {
+		if strings.HasPrefix(s, scheme) {
+			return true
+		}
+	}
+	return strings.Contains(s, "=")
+}
+
+// ParseConnString parses a libpq connection URI
+//
+//	postgresql://[user[:password]@][host][:port][,...][/dbname][?param=value&...]
+//
+// or a "key=value ..." conninfo string into a Connection.
+func ParseConnString(s string) (*Connection, error) {
+	for _, scheme := range uriSchemes {
+		if strings.HasPrefix(s, scheme) {
+			return parseURI(strings.TrimPrefix(s, scheme))
+		}
+	}
+	return parseConnInfo(s)
+}
+
+// parseURI parses a connection URI without its scheme.
+func parseURI(s string) (*Connection, error) {
+	opts := map[string]string{}
+	rest, query, _ := strings.Cut(s, "?")
+	rest, dbname, _ := strings.Cut(rest, "/")
+	if i := strings.LastIndex(rest, "@"); i != -1 {
+		user, password, hasPassword := strings.Cut(rest[:i], ":")
+		if err := setUnescaped(opts, "user", user); err != nil {
+			return nil, err
+		}
+		if hasPassword {
+			if err := setUnescaped(opts, "password", password); err != nil {
+				return nil, err
+			}
+		}
+		rest = rest[i+1:]
+	}
+	if err := setUnescaped(opts, "host", rest); err != nil {
+		return nil, err
+	}
+	if err := setUnescaped(opts, "dbname", dbname); err != nil {
+		return nil, err
+	}
+
+	if query != "" {
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid URI parameters")
+		}
+		for key, vals := range values {
+			opts[key] = vals[len(vals)-1]
+		}
+	}
+	return connectionFromOptions(opts)
+}
+
+// setUnescaped sets the percent-decoded value of a URI part, when not empty.
+func setUnescaped(opts map[string]string, key, value string) error {
+	if value == "" {
+		return nil
+	}
+	v, err := url.PathUnescape(value)
+	if err != nil {
+		return errors.Wrapf(err, "invalid %s in URI", key)
+	}
+	opts[key] = v
+	return nil
+}
+
+// parseConnInfo parses a "key=value ..." conninfo string, values may be
+// single quoted with backslash escapes.
+func parseConnInfo(s string) (*Connection, error) {
+	opts := map[string]string{}
+	r := []rune(s)
+	i := 0
+	skipSpaces := func() {
+		for i < len(r) && unicode.IsSpace(r[i]) {
+			i++
+		}
+	}
+	for {
+		skipSpaces()
+		if i >= len(r) {
+			return connectionFromOptions(opts)
+		}
+		start := i
+		for i < len(r) && r[i] != '=' && !unicode.IsSpace(r[i]) {
+			i++
+		}
+		key := string(r[start:i])
+		skipSpaces()
+		if i >= len(r) || r[i] != '=' {
+			return nil, errors.Errorf("missing \"=\" after %q in connection info string", key)
+		}
+		i++
+		skipSpaces()
+
+		var value strings.Builder
+		if i < len(r) && r[i] == '\'' {
+			i++
+			for ; i < len(r) && r[i] != '\''; i++ {
+				if r[i] == '\\' && i+1 < len(r) {
+					i++
+				}
+				value.WriteRune(r[i])
+			}
+			if i >= len(r) {
+				return nil, errors.New("unterminated quoted string in connection info string")
+			}
+			i++
+		} else {
+			for ; i < len(r) && !unicode.IsSpace(r[i]); i++ {
+				if r[i] == '\\' && i+1 < len(r) {
+					i++
+				}
+				value.WriteRune(r[i])
+			}
+		}
+		opts[key] = value.String()
+	}
+}
+
+// connectionFromOptions builds a Connection from libpq connection options.
+func connectionFromOptions(opts map[string]string) (*Connection, error) {
+	c := &Connection{}
+	for key, value := range opts {
+		if key == "port" {
+			continue
+		}
+		if err := c.setOption(key, value); err != nil {
+			return nil, err
+		}
+	}
+	// ports are matched with the hosts once they are known
+	if port, ok := opts["port"]; ok {
+		if err := c.setPorts(port); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// setOption sets the connection option key, named as in libpq.
+func (c *Connection) setOption(key, value string) error {
+	switch key {
+	case "host", "hostaddr":
+		c.Host = value
+	case "user":
+		c.Username = value
+	case "password":
+		c.Password = value
+	case "dbname":
+		c.DBName = value
+	case "application_name":
+		c.AppName = value
+	case "connect_timeout":
+		secs, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.Errorf("invalid connect_timeout value: %q", value)
+		}
+		c.ConnTimeout = time.Duration(secs) * time.Second
+	case "sslmode":
+		if err := CheckSSLMode(value); err != nil {
+			return err
+		}
+		c.SSLMode = value
+	case "sslcert":
+		c.SSLCert = value
+	case "sslkey":
+		c.SSLKey = value
+	case "sslrootcert":
+		c.SSLRootCert = value
+	case "sslcrl":
+		c.SSLCRL = value
+	case "sslenccert":
+		c.SSLEncCert = value
+	case "sslenckey":
+		c.SSLEncKey = value
+	case "target_session_attrs":
+		if err := CheckTargetSessionAttrs(value); err != nil {
+			return err
+		}
+		c.TargetSessionAttrs = value
+	case "service":
+		c.Service = value
+	default:
+		return errors.Errorf("invalid connection option %q", key)
+	}
+	return nil
+}
+
+// setPorts sets the port, or the comma separated ports of each host.
+func (c *Connection) setPorts(value string) error {
+	var ports []int
+	for _, s := range strings.Split(value, ",") {
+		p, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return errors.Errorf("invalid port number: %q", s)
+		}
+		ports = append(ports, p)
+	}
+	if len(ports) == 1 {
+		c.Port = ports[0]
+		return nil
+	}
+	addrs := c.Addrs()
+	if len(addrs) != len(ports) {
+		return errors.Errorf("could not match %d port numbers to %d hosts", len(ports), len(addrs))
+	}
+	hosts := make([]string, 0, len(addrs))
+	for i, a := range addrs {
+		a.Port = ports[i]
+		hosts = append(hosts, a.String())
+	}
+	c.Host = strings.Join(hosts, ",")
+	return nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"gsmate/internal/logger"
+	"gsmate/internal/utils"
+
+	"github.com/pkg/errors"
+	"github.com/vimiix/pkg/file"
+	"gopkg.in/ini.v1"
+)
+
+// PassFile returns the path of the password file, $PGPASSFILE or
+// ~/.pgpass (%APPDATA%\postgresql\pgpass.conf on Windows).
+func PassFile() string {
+	if s, ok := utils.Getenv("PGPASSFILE"); ok {
+		return file.ExpandHomePath(s)
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "postgresql", "pgpass.conf")
+	}
+	return file.ExpandHomePath("~/.pgpass")
+}
+
+// LookupPassword returns the password of the first line of the password file
+// path matching the connection, as "hostname:port:database:username:password"
+// where each of the first four fields may be *. The file is ignored when
+// readable by group or others.
+func LookupPassword(path string, c *Connection) (string, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	if !st.Mode().IsRegular() {
+		logger.Warn("password file %q is not a plain file", path)
+		return "", nil
+	}
+	if runtime.GOOS != "windows" && st.Mode().Perm()&0o077 != 0 {
+		logger.Warn("password file %q has group or world access; permissions should be u=rw (0600) or less", path)
+		return "", nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	addrs := c.Addrs()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := splitPassLine(line)
+		if len(fields) != 5 {
+			continue
+		}
+		for _, a := range addrs {
+			if passFieldMatches(fields[0], passHost(a.Host)) &&
+				passFieldMatches(fields[1], strconv.Itoa(a.Port)) &&
+				passFieldMatches(fields[2], c.DBName) &&
+				passFieldMatches(fields[3], c.Username) {
+				return fields[4], nil
+			}
+		}
+	}
+	return "", errors.Wrapf(scanner.Err(), "read password file %s", path)
+}
+
+// passHost returns the name a password file line uses for host, which is
+// localhost for the default host and socket directories.
+func passHost(host string) string {
+	if host == "" || strings.HasPrefix(host, "/") {
+		return "localhost"
+	}
+	return host
+}
+
+// passFieldMatches reports whether a password file field matches value.
+func passFieldMatches(field, value string) bool {
+	return field == "*" || field == value
+}
+
+// splitPassLine splits a password file line on the colons not escaped by a
+// backslash, unescaping the fields.
+func splitPassLine(line string) []string {
+	var fields []string
+	var sb strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line):
+			i++
+			sb.WriteByte(line[i])
+		case line[i] == ':':
+			fields = append(fields, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteByte(line[i])
+		}
+	}
+	return append(fields, sb.String())
+}
+
+// serviceFiles returns the connection service files in lookup order,
+// $PGSERVICEFILE or ~/.pg_service.conf, then $PGSYSCONFDIR/pg_service.conf.
+func serviceFiles() []string {
+	var files []string
+	if s, ok := utils.Getenv("PGSERVICEFILE"); ok {
+		files = append(files, file.ExpandHomePath(s))
+	} else if runtime.GOOS == "windows" {
+		files = append(files, filepath.Join(os.Getenv("APPDATA"), "postgresql", ".pg_service.conf"))
+	} else {
+		files = append(files, file.ExpandHomePath("~/.pg_service.conf"))
+	}
+	if s, ok := utils.Getenv("PGSYSCONFDIR"); ok {
+		files = append(files, filepath.Join(s, "pg_service.conf"))
+	}
+	return files
+}
+
+// LoadService reads the connection options of the service called name from
+// the first connection service file defining it.
+func LoadService(name string) (*Connection, error) {
+	for _, path := range serviceFiles() {
+		if !file.Exists(path) {
+			continue
+		}
+		f, err := ini.Load(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "load service file %s", path)
+		}
+		sec, err := f.GetSection(name)
+		if err != nil {
+			continue
+		}
+		opts := sec.KeysHash()
+		if _, ok := opts["service"]; ok {
+			return nil, errors.Errorf("nested service specifications not supported in service file %s", path)
+		}
+		c, err := connectionFromOptions(opts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "service %q in %s", name, path)
+		}
+		return c, nil
+	}
+	return nil, errors.Errorf("definition of service %q not found", name)
+}
+
+// MergeService merges the options of the connection service called name
+// into c.
+func (c *Connection) MergeService(name string) error {
+	svc, err := LoadService(name)
+	if err != nil {
+		return err
+	}
+	c.Merge(svc)
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, name, content string, perm os.FileMode) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), perm))
+	return path
+}
+
+func TestLookupPassword(t *testing.T) {
+	path := writeFile(t, ".pgpass", `# comment
+db1:5432:postgres:omm:first
+*:26000:*:omm:any\:host
+localhost:*:*:admin:local
+`, 0o600)
+
+	tests := []struct {
+		name string
+		conn Connection
+		exp  string
+	}{
+		{"Exact", Connection{Host: "db1", Port: 5432, DBName: "postgres", Username: "omm"}, "first"},
+		{"Wildcards", Connection{Host: "db2", Port: 26000, DBName: "app", Username: "omm"}, "any:host"},
+		{"Second Host", Connection{Host: "db3:1,db1:5432", DBName: "postgres", Username: "omm"}, "first"},
+		{"Socket Directory", Connection{Host: "/tmp", Port: 5432, DBName: "app", Username: "admin"}, "local"},
+		{"No Match", Connection{Host: "db1", Port: 5432, DBName: "postgres", Username: "other"}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			password, err := LookupPassword(path, &test.conn)
+			assert.NoError(t, err)
+			assert.Equal(t, test.exp, password)
+		})
+	}
+
+	t.Run("Missing File", func(t *testing.T) {
+		password, err := LookupPassword(filepath.Join(t.TempDir(), "missing"), &Connection{})
+		assert.NoError(t, err)
+		assert.Empty(t, password)
+	})
+
+	if runtime.GOOS != "windows" {
+		t.Run("Insecure Permissions", func(t *testing.T) {
+			path := writeFile(t, ".pgpass", "*:*:*:*:secret\n", 0o644)
+			password, err := LookupPassword(path, &Connection{Host: "db1"})
+			assert.NoError(t, err)
+			assert.Empty(t, password)
+		})
+	}
+}
+
+func TestLoadService(t *testing.T) {
+	path := writeFile(t, "pg_service.conf", `# services
+[prod]
+host=10.0.0.1
+port=5432
+dbname=app
+sslmode=verify-full
+
+[nested]
+service=prod
+`, 0o600)
+	t.Setenv("PGSERVICEFILE", path)
+	t.Setenv("PGSYSCONFDIR", t.TempDir())
+
+	c, err := LoadService("prod")
+	assert.NoError(t, err)
+	assert.Equal(t, Connection{Host: "10.0.0.1", Port: 5432, DBName: "app", SSLMode: "verify-full"}, *c)
+
+	_, err = LoadService("nested")
+	assert.Error(t, err)
+	_, err = LoadService("missing")
+	assert.Error(t, err)
+}
@@ -0,0 +1,224 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"gsmate/internal/utils"
+
+	"github.com/pkg/errors"
+	"gopkg.in/ini.v1"
+)
+
+// printSection is the config file section of the preferred print settings.
+const printSection = "print"
+
+// PrintFormats are the supported values of the format print option.
+var PrintFormats = []string{
+	"aligned", "unaligned", "asciidoc", "csv", "html", "json",
+	"latex", "latex-longtable", "troff-ms", "vertical",
+}
+
+// printOptionValues are the accepted values of the enumerated print
+// options, the boolean ones accepting anything utils.ParseBool does.
+var printOptionValues = map[string][]string{
+	"expanded":                 {"auto", "on", "off"},
+	"format":                   PrintFormats,
+	"linestyle":                {"ascii", "old-ascii", "unicode"},
+	"pager":                    {"always", "on", "off"},
+	"unicode_border_linestyle": {"single", "double"},
+	"unicode_column_linestyle": {"single", "double"},
+	"unicode_header_linestyle": {"single", "double"},
+}
+
+// boolPrintOptions are the print options toggled on and off.
+var boolPrintOptions = map[string]bool{
+	"fieldsep_zero":  true,
+	"footer":         true,
+	"numericlocale":  true,
+	"recordsep_zero": true,
+	"tuples_only":    true,
+}
+
+// intPrintOptions are the print options taking a non-negative integer.
+var intPrintOptions = map[string]bool{
+	"border":          true,
+	"columns":         true,
+	"pager_min_lines": true,
+}
+
+// newPrintConfig returns the default print settings.
+func newPrintConfig(cfg *Config, locale string) map[string]string {
+	usePager := "on"
+	if !cfg.UsePager {
+		usePager = "off"
+	}
+	return map[string]string{
+		"border":                   "1",
+		"columns":                  "0",
+		"csv_fieldsep":             ",",
+		"expanded":                 "off",
+		"fieldsep":                 "|",
+		"fieldsep_zero":            "off",
+		"footer":                   "on",
+		"format":                   "aligned",
+		"linestyle":                "ascii",
+		"locale":                   locale,
+		"null":                     "",
+		"numericlocale":            "off",
+		"pager":                    usePager,
+		"pager_cmd":                cfg.Pager,
+		"pager_min_lines":          "0",
+		"recordsep":                "\n",
+		"recordsep_zero":           "off",
+		"tableattr":                "",
+		"time":                     "RFC3339Nano",
+		"timezone":                 "",
+		"title":                    "",
+		"tuples_only":              "off",
+		"unicode_border_linestyle": "single",
+		"unicode_column_linestyle": "single",
+		"unicode_header_linestyle": "single",
+	}
+}
+
+// PrintOptions returns the names of the print options, sorted.
+func PrintOptions() []string {
+	names := make([]string, 0, len(printConfig))
+	for name := range printConfig {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PrintOptionValues returns the accepted values of the print option name,
+// nil when it takes any value.
+func PrintOptionValues(name string) []string {
+	if boolPrintOptions[name] {
+		return []string{"on", "off"}
+	}
+	return printOptionValues[name]
+}
+
+// IsBoolPrintOption reports whether the print option name is either on or
+// off.
+func IsBoolPrintOption(name string) bool {
+	return boolPrintOptions[name]
+}
+
+// IsIntPrintOption reports whether the print option name is a number.
+func IsIntPrintOption(name string) bool {
+	return intPrintOptions[name]
+}
+
+// SetPrintOption validates and sets the print option name to value.
+func SetPrintOption(name, value string) error {
+	if _, ok := printConfig[name]; !ok {
+		return errors.Errorf("unrecognized print option %q", name)
+	}
+	v, err := checkPrintOption(name, value)
+	if err != nil {
+		return err
+	}
+	printConfig[name] = v
+	return nil
+}
+
+// checkPrintOption validates value of the print option name, returning its
+// normalized form: on or off for booleans, and the full value of an
+// enumerated option given by a unique prefix.
+func checkPrintOption(name, value string) (string, error) {
+	switch {
+	case boolPrintOptions[name]:
+		b, err := utils.ParseBool(value)
+		if err != nil {
+			return "", errors.Wrap(err, name)
+		}
+		return onOff(b), nil
+	case intPrintOptions[name]:
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return "", errors.Errorf("%s: invalid value %q, must be a non-negative integer", name, value)
+		}
+		return strconv.Itoa(n), nil
+	case name == "csv_fieldsep":
+		if len(value) != 1 || value == "\"" || value == "\n" || value == "\r" {
+			return "", errors.Errorf("csv_fieldsep: must be a single one-byte character, not a quote or newline")
+		}
+		return value, nil
+	}
+	values, ok := printOptionValues[name]
+	if !ok {
+		return value, nil
+	}
+	if name == "expanded" || name == "pager" {
+		// accept the boolean spellings too
+		if b, err := utils.ParseBool(value); err == nil {
+			return onOff(b), nil
+		}
+	}
+	var match string
+	value = strings.ToLower(value)
+	for _, v := range values {
+		if v == value {
+			return v, nil
+		}
+		if value != "" && strings.HasPrefix(v, value) {
+			if match != "" {
+				return "", errors.Errorf("%s: ambiguous abbreviation %q matches both %q and %q", name, value, match, v)
+			}
+			match = v
+		}
+	}
+	if match == "" {
+		return "", errors.Errorf("%s: invalid value %q, must be one of: %s", name, value, strings.Join(values, ", "))
+	}
+	return match, nil
+}
+
+// loadPrintOptions reads the preferred print settings of the [print]
+// section, applied over the defaults by Init.
+func loadPrintOptions(f *ini.File) (map[string]string, error) {
+	sec, err := f.GetSection(printSection)
+	if err != nil {
+		// no such section
+		return nil, nil
+	}
+	defaults := newPrintConfig(&Config{}, "")
+	opts := make(map[string]string, len(sec.Keys()))
+	for _, key := range sec.Keys() {
+		name := key.Name()
+		if _, ok := defaults[name]; !ok {
+			return nil, errors.Errorf("unrecognized print option %q", name)
+		}
+		v, err := checkPrintOption(name, key.String())
+		if err != nil {
+			return nil, err
+		}
+		opts[name] = v
+	}
+	return opts, nil
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/ini.v1"
+)
+
+func TestSetPrintOption(t *testing.T) {
+	printConfig = newPrintConfig(&Config{}, "en-US")
+	defer func() { printConfig = nil }()
+
+	tests := []struct {
+		name, value, exp string
+		err              bool
+	}{
+		{"border", "2", "2", false},
+		{"border", "-1", "", true},
+		{"columns", "abc", "", true},
+		{"expanded", "auto", "auto", false},
+		{"expanded", "true", "on", false},
+		{"expanded", "o", "", true},
+		{"format", "unaligned", "unaligned", false},
+		{"format", "h", "html", false},
+		{"format", "latex", "latex", false},
+		{"format", "l", "", true},
+		{"format", "wrapped", "", true},
+		{"linestyle", "UNICODE", "unicode", false},
+		{"linestyle", "u", "unicode", false},
+		{"pager", "always", "always", false},
+		{"pager", "0", "off", false},
+		{"tuples_only", "yes", "on", false},
+		{"footer", "maybe", "", true},
+		{"csv_fieldsep", ";", ";", false},
+		{"csv_fieldsep", "ab", "", true},
+		{"null", "(null)", "(null)", false},
+		{"unicode_border_linestyle", "double", "double", false},
+		{"nosuch", "on", "", true},
+	}
+	for _, test := range tests {
+		err := SetPrintOption(test.name, test.value)
+		if test.err {
+			assert.Error(t, err, "%s %s", test.name, test.value)
+			continue
+		}
+		assert.NoError(t, err, "%s %s", test.name, test.value)
+		assert.Equal(t, test.exp, printConfig[test.name], "%s %s", test.name, test.value)
+	}
+}
+
+func TestLoadPrintOptions(t *testing.T) {
+	f, err := ini.Load([]byte(`
+[print]
+border = 2
+expanded = auto
+null = (null)
+tuples_only = yes
+`))
+	assert.NoError(t, err)
+	opts, err := loadPrintOptions(f)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"border":      "2",
+		"expanded":    "auto",
+		"null":        "(null)",
+		"tuples_only": "on",
+	}, opts)
+
+	f, err = ini.Load([]byte("[print]\nborder = thick\n"))
+	assert.NoError(t, err)
+	_, err = loadPrintOptions(f)
+	assert.Error(t, err)
+
+	f, err = ini.Load([]byte("prompt = x\n"))
+	assert.NoError(t, err)
+	opts, err = loadPrintOptions(f)
+	assert.NoError(t, err)
+	assert.Nil(t, opts)
+}
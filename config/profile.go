@@ -0,0 +1,100 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/ini.v1"
+)
+
+// profileSectionPrefix prefixes the config file sections of named
+// connection profiles, eg [connection.prod].
+const profileSectionPrefix = "connection."
+
+// Profile is a named connection of the config file. Keys missing from its
+// section are inherited from the [connection] section.
+type Profile struct {
+	Name       string
+	Connection Connection
+	// Prompt overrides the prompt format when not empty.
+	Prompt string
+	// OnErrorStop overrides on_error_stop when not nil.
+	OnErrorStop *bool
+}
+
+// loadProfiles reads the [connection.<name>] sections of f, sorted by name.
+func loadProfiles(f *ini.File) ([]*Profile, error) {
+	var profiles []*Profile
+	for _, sec := range f.Sections() {
+		name, ok := strings.CutPrefix(sec.Name(), profileSectionPrefix)
+		if !ok || name == "" {
+			continue
+		}
+		p := &Profile{Name: name}
+		if err := sec.MapTo(&p.Connection); err != nil {
+			return nil, errors.Wrapf(err, "load profile %s", name)
+		}
+		if sec.HasKey("prompt") {
+			p.Prompt = sec.Key("prompt").String()
+		}
+		if sec.HasKey("on_error_stop") {
+			v, err := sec.Key("on_error_stop").Bool()
+			if err != nil {
+				return nil, errors.Wrapf(err, "load profile %s: on_error_stop", name)
+			}
+			p.OnErrorStop = &v
+		}
+		profiles = append(profiles, p)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Name < profiles[j].Name
+	})
+	return profiles, nil
+}
+
+// Profiles returns the connection profiles of the config file, sorted by
+// name.
+func (c *Config) Profiles() []*Profile {
+	return c.profiles
+}
+
+// Profile returns the connection profile called name.
+func (c *Config) Profile(name string) (*Profile, bool) {
+	for _, p := range c.profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// ApplyProfile merges the connection profile called name into c.
+func (c *Config) ApplyProfile(name string) error {
+	p, ok := c.Profile(name)
+	if !ok {
+		return errors.Errorf("profile %q not found in %s", name, ConfigFile())
+	}
+	c.Connection.Merge(&p.Connection)
+	if p.Prompt != "" {
+		c.Prompt = p.Prompt
+	}
+	if p.OnErrorStop != nil {
+		c.OnErrorStop = *p.OnErrorStop
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/ini.v1"
+)
+
+func TestLoadProfiles(t *testing.T) {
+	f, err := ini.Load([]byte(`
+[connection]
+host = localhost
+port = 26000
+user = omm
+
+[connection.prod]
+host = 10.0.0.1
+prompt = "[prod] $u@$h"
+on_error_stop = off
+query_timeout = 30s
+
+[connection.dev]
+dbname = devdb
+`))
+	assert.NoError(t, err)
+
+	profiles, err := loadProfiles(f)
+	assert.NoError(t, err)
+	assert.Len(t, profiles, 2)
+	assert.Equal(t, "dev", profiles[0].Name)
+	assert.Equal(t, "omm@localhost:26000/devdb", profiles[0].Connection.Address())
+	assert.Nil(t, profiles[0].OnErrorStop)
+
+	cfg := &Config{OnErrorStop: true, Prompt: defaultPrompt, profiles: profiles}
+	assert.NoError(t, cfg.ApplyProfile("prod"))
+	assert.Equal(t, "10.0.0.1", cfg.Host)
+	assert.Equal(t, 26000, cfg.Port)
+	assert.Equal(t, "[prod] $u@$h", cfg.Prompt)
+	assert.False(t, cfg.OnErrorStop)
+	assert.Equal(t, 30*time.Second, cfg.QueryTimeout)
+
+	assert.Error(t, cfg.ApplyProfile("missing"))
+}
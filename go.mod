@@ -13,6 +13,7 @@ require (
 	github.com/vimiix/pkg v0.0.0-20240925012329-7b21741d14a6
 	github.com/xo/tblfmt v0.13.2
 	github.com/xo/terminfo v0.0.0-20220910002029-abceb7e1c41e
+	golang.org/x/crypto v0.25.0
 	golang.org/x/term v0.25.0
 	gopkg.in/ini.v1 v1.67.0
 )
@@ -33,7 +34,6 @@ require (
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	github.com/tjfoc/gmsm v1.4.1 // indirect
 	github.com/xrash/smetrics v0.0.0-20240312152122-5f08fbb34913 // indirect
-	golang.org/x/crypto v0.25.0 // indirect
 	golang.org/x/sys v0.26.0 // indirect
 	golang.org/x/text v0.16.0 // indirect
 	golang.org/x/xerrors v0.0.0-20231012003039-104605ab7028 // indirect
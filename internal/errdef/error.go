@@ -26,4 +26,8 @@ const (
 	ErrUnterminatedQuotedString Error = "unterminated quoted string"
 	ErrWrongNumberOfArguments   Error = "wrong number of arguments"
 	ErrNotSupported             Error = "not supported"
+	ErrUnknownCommand           Error = "invalid command, try \\? for help"
+	ErrNoTerminal               Error = "no terminal to read the password from"
+	ErrPasswordMismatch         Error = "passwords didn't match"
+	ErrMissingRequiredArgument  Error = "missing required argument"
 )
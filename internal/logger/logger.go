@@ -28,6 +28,9 @@ var (
 	slience bool
 	level   LogLevel
 	logger  = log.New(os.Stderr, "", 0)
+	// fileLogger additionally receives the messages, without colors, when
+	// a log file is set.
+	fileLogger *log.Logger
 )
 
 type LogLevel uint8
@@ -83,15 +86,49 @@ func MuteLogger() {
 	slience = true
 }
 
+// SetLogFile appends the log messages and the messages written with Print to
+// the file at path as well.
+func SetLogFile(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	fileLogger = log.New(f, "", 0)
+	return nil
+}
+
+// colorize colors message for lvl.
+func colorize(lvl LogLevel, message string) string {
+	switch {
+	case lvl >= ErrorLevel:
+		return color.RedString("%s", message)
+	case lvl == WarnLevel:
+		return color.YellowString("%s", message)
+	}
+	return message
+}
+
 func defaultPrint(lvl LogLevel, message string) {
 	if slience {
 		return
 	}
 	if lvl >= level {
 		ts := time.Now().Format("2006-01-02T15:04:05.000")
-		logger.Print(
-			strings.Join([]string{ts, "[" + lvl.String() + "]", message}, " "),
-		)
+		prefix := ts + " [" + lvl.String() + "] "
+		logger.Print(prefix + colorize(lvl, message))
+		if fileLogger != nil {
+			fileLogger.Print(prefix + message)
+		}
+	}
+}
+
+// Print writes message as is, colored for lvl, regardless of the log level,
+// as for the messages of the server.
+func Print(lvl LogLevel, message string) {
+	logger.Print(colorize(lvl, message))
+	if fileLogger != nil {
+		ts := time.Now().Format("2006-01-02T15:04:05.000")
+		fileLogger.Print(strings.Join([]string{ts, "[" + lvl.String() + "]", message}, " "))
 	}
 }
 
@@ -115,14 +152,14 @@ func Info(format string, v ...any) {
 }
 
 func Warn(format string, v ...any) {
-	printFunc(WarnLevel, color.YellowString(format, v...))
+	printFunc(WarnLevel, fmt.Sprintf(format, v...))
 }
 
 func Error(format string, v ...any) {
-	printFunc(ErrorLevel, color.RedString(format, v...))
+	printFunc(ErrorLevel, fmt.Sprintf(format, v...))
 }
 
 func Fatal(format string, v ...any) {
-	printFunc(ErrorLevel, color.RedString(format, v...))
+	printFunc(ErrorLevel, fmt.Sprintf(format, v...))
 	os.Exit(1)
 }
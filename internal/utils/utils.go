@@ -20,9 +20,11 @@ import (
 	"strings"
 	"unicode"
 
+	"gsmate/internal/errdef"
 	"gsmate/internal/logger"
 
 	"github.com/fatih/color"
+	"github.com/pkg/errors"
 	"golang.org/x/term"
 )
 
@@ -65,6 +67,24 @@ func PrintError(err any) {
 	fmt.Fprintln(os.Stderr, color.RedString("error: %v", err))
 }
 
+// ReadPassword prints prompt and reads a password without echo from the
+// controlling terminal, falling back to the standard input when there is no
+// /dev/tty (eg on Windows).
+func ReadPassword(prompt string) (string, error) {
+	in, out := os.Stdin, os.Stderr
+	if tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0); err == nil {
+		defer tty.Close()
+		in, out = tty, tty
+	}
+	if !term.IsTerminal(int(in.Fd())) {
+		return "", errdef.ErrNoTerminal
+	}
+	fmt.Fprint(out, prompt)
+	b, err := term.ReadPassword(int(in.Fd()))
+	fmt.Fprintln(out)
+	return string(b), err
+}
+
 // Getenv gets the value of one or more environment variables.
 //
 // It takes one or more environment variable names as parameters and returns
@@ -104,3 +124,23 @@ func EmptyStr(s string) bool {
 	})
 	return i == -1
 }
+
+// ParseBool parses a boolean the way psql does for its variables, accepting
+// any unambiguous prefix of on, off, true, false, yes and no, as well as 1
+// and 0, case-insensitively.
+func ParseBool(s string) (bool, error) {
+	v := strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case v == "":
+	case v == "1", strings.HasPrefix("true", v), strings.HasPrefix("yes", v):
+		return true, nil
+	case v == "0", strings.HasPrefix("false", v), strings.HasPrefix("no", v):
+		return false, nil
+	// "o" alone is ambiguous
+	case len(v) >= 2 && strings.HasPrefix("on", v):
+		return true, nil
+	case len(v) >= 2 && strings.HasPrefix("off", v):
+		return false, nil
+	}
+	return false, errors.Errorf("invalid boolean value %q", s)
+}
@@ -43,3 +43,20 @@ func TestChunks(t *testing.T) {
 		assert.Equal(t, expected, chunks)
 	})
 }
+
+func TestParseBool(t *testing.T) {
+	for _, s := range []string{"on", "ON", "true", "t", "yes", "y", "1"} {
+		b, err := ParseBool(s)
+		assert.NoError(t, err, s)
+		assert.True(t, b, s)
+	}
+	for _, s := range []string{"off", "of", "false", "F", "no", "n", "0"} {
+		b, err := ParseBool(s)
+		assert.NoError(t, err, s)
+		assert.False(t, b, s)
+	}
+	for _, s := range []string{"", "o", "maybe", "2"} {
+		_, err := ParseBool(s)
+		assert.Error(t, err, s)
+	}
+}
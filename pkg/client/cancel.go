@@ -0,0 +1,67 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"gsmate/internal/logger"
+)
+
+// cancelTimeout bounds the time sending a cancel request may take.
+const cancelTimeout = 10 * time.Second
+
+// errQueryCanceled is the SQLSTATE of a statement canceled on request.
+const errQueryCanceled = "57014"
+
+// cancelOnInterrupt makes SIGINT cancel the statement running on the
+// session connection instead of terminating gsmate, until the returned
+// function is called.
+func (c *DBClient) cancelOnInterrupt() func() {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-sig:
+				c.cancelQuery()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sig)
+		close(done)
+	}
+}
+
+// cancelQuery asks the server to cancel the statement running on the session
+// connection. The request is sent over another connection of the pool, as
+// the session connection is busy with the statement.
+func (c *DBClient) cancelQuery() {
+	ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+	defer cancel()
+	if _, err := c.db.ExecContext(ctx, queryCancelBackend, c.pid); err != nil {
+		logger.Error("could not send cancel request: %s", err)
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Cancel request sent")
+}
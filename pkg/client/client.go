@@ -15,12 +15,16 @@ package client
 // limitations under the License.
 
 import (
+	"bufio"
 	"context"
 	"database/sql"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"gsmate/config"
 	"gsmate/internal/errdef"
@@ -29,10 +33,9 @@ import (
 	"gsmate/pkg/client/metadata"
 	"gsmate/pkg/version"
 
-	_ "gitee.com/opengauss/openGauss-connector-go-pq"
+	pq "gitee.com/opengauss/openGauss-connector-go-pq"
 	"github.com/pkg/errors"
 	"github.com/vimiix/go-prompt"
-	"github.com/xo/tblfmt"
 )
 
 var dummyExecutor = func(string) {}
@@ -40,28 +43,57 @@ var dummyExecutor = func(string) {}
 type DBClient struct {
 	cfg          *config.Config
 	db           *sql.DB
-	tx           *sql.Tx
 	version      string
 	prompt       *prompt.Prompt
 	promptPrefix string
 	history      *History
 	stmt         *Stmt
+	// node is the server of the connection among the configured hosts.
+	node node
+	// sslInfo describes the SSL connection, empty when not using SSL.
+	sslInfo string
+	// catalogColumns caches the optional catalog columns of the server.
+	catalogColumns map[string]bool
+	// session records the session parameters to restore on reconnect.
+	session *sessionState
+	// interactive is set while reading statements from the prompt.
+	interactive bool
+	// conn is the connection of db all statements of the session run on.
+	conn *sql.Conn
+	// txStatus is the transaction status of the session.
+	txStatus metacmd.TxStatus
+	// vars are the variables set with \set, except the special ones.
+	vars map[string]string
+	// pid is the server process of conn, to cancel its statements.
+	pid int64
+	// notices are the server notices held while rendering a result.
+	notices noticeQueue
+	// encoding is the client encoding of the session.
+	encoding string
+	// lastErr is the last error of the server, raised by lastErrQuery.
+	lastErr      *pq.Error
+	lastErrQuery string
+	// lastQuery is the previous query, run again by \g on an empty buffer.
+	lastQuery string
+	// out is the output set by \o or \g, nil for the standard output.
+	out io.WriteCloser
 }
 
 func New(cfg *config.Config) (*DBClient, error) {
-	db, err := sql.Open("opengauss", cfg.GetDSN())
+	c := &DBClient{
+		cfg:     cfg,
+		session: newSessionState(),
+	}
+	db, n, err := connect(cfg, c.handleNotice)
 	if err != nil {
 		return nil, err
 	}
-
-	history, err := NewHistory(cfg.MaxHistory)
-	if err != nil {
+	if err := c.attach(db, n); err != nil {
 		return nil, err
 	}
-	c := &DBClient{
-		cfg:     cfg,
-		db:      db,
-		history: history,
+
+	if c.history, err = NewHistory(cfg.MaxHistory); err != nil {
+		return nil, err
 	}
 
 	cc := &CmdCompleter{client: c}
@@ -69,9 +101,14 @@ func New(cfg *config.Config) (*DBClient, error) {
 	c.prompt = prompt.New(dummyExecutor,
 		cc.Complete(),
 		prompt.OptionTitle("gsmate"),
-		prompt.OptionHistory(history.Records()),
+		prompt.OptionHistory(c.history.Records()),
 		prompt.OptionInputTextColor(prompt.Yellow),
 		prompt.OptionLivePrefix(c.LivePrefix()),
+		// the prompt clears the line, the statement buffer has to follow
+		prompt.OptionAddKeyBind(prompt.KeyBind{
+			Key: prompt.ControlC,
+			Fn:  func(*prompt.Buffer) { c.stmt.Reset(nil) },
+		}),
 	)
 
 	c.stmt = NewStmt(func() ([]rune, error) {
@@ -89,52 +126,106 @@ func New(cfg *config.Config) (*DBClient, error) {
 
 func (c *DBClient) LivePrefix() func() (string, bool) {
 	if c.promptPrefix == "" {
-		c.promptPrefix = c.cfg.PromptPrefix()
+		c.refreshPromptPrefix()
 	}
 	return func() (string, bool) {
-		status := "=# "
+		status := "="
 		if len(c.stmt.Buf) > 0 && !c.stmt.ready {
-			status = "-# "
+			status = "-"
+		}
+		switch c.txStatus {
+		case metacmd.TxActive:
+			status += "*"
+		case metacmd.TxFailed:
+			status += "!"
 		}
-		return c.promptPrefix + status, true
+		return c.promptPrefix + status + "# ", true
 	}
 }
 
 func (c *DBClient) initServerInfo() error {
-	var err error
-	rows, err := c.DB().Query(queryDBVersion)
-	if err != nil {
+	err := c.DB().QueryRow(queryDBVersion).Scan(&c.version)
+	if err != nil && err != sql.ErrNoRows {
 		return err
 	}
-	defer rows.Close()
-	if rows.Next() {
-		if err = rows.Scan(&c.version); err != nil {
-			return err
-		}
-	}
 
 	logger.Debug("get server version: %s", c.version)
-	return err
+	c.initSSLInfo()
+	c.initEncoding()
+	return nil
 }
 
-func (c *DBClient) DB() DB {
-	if c.tx != nil {
-		return c.tx
+// initEncoding reads the client encoding of the session.
+func (c *DBClient) initEncoding() {
+	if err := c.DB().QueryRow(queryClientEncoding).Scan(&c.encoding); err != nil {
+		logger.Debug("get client encoding: %s", err)
+	}
+}
+
+// initSSLInfo reads the protocol and cipher of the connection when using
+// SSL. openGauss has no pg_stat_ssl view, so they are read from the TLS
+// state of the driver connection.
+func (c *DBClient) initSSLInfo() {
+	c.sslInfo = ""
+	err := c.conn.Raw(func(driverConn any) error {
+		if state, ok := tlsState(driverConn); ok {
+			c.sslInfo = sslInfo(state)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Debug("get ssl info: %s", err)
 	}
-	return c.db
+}
+
+// DB returns the session connection, so that transaction blocks opened
+// with BEGIN span the statements that follow.
+func (c *DBClient) DB() DB {
+	return sessionConn{c.conn}
 }
 
 type CloseFunc func()
 
 func (c *DBClient) query(q string, args ...any) (*sql.Rows, CloseFunc, error) {
+	rows, closeFunc, err := c.runQuery(q, args...)
+	c.handleConnError(err)
+	return rows, closeFunc, err
+}
+
+// runQuery runs q, which can be canceled with Ctrl-C until the rows are
+// closed.
+func (c *DBClient) runQuery(q string, args ...any) (*sql.Rows, CloseFunc, error) {
 	logger.Debug("query: %s", q)
+	ctx, cancel := c.queryContext()
+	stop := c.cancelOnInterrupt()
+	rows, err := c.DB().QueryContext(ctx, q, args...)
+	if err != nil {
+		stop()
+		cancel()
+		return nil, func() {}, err
+	}
+	return rows, func() { cancel(); rows.Close(); stop() }, nil
+}
+
+// exec runs the statement q returning no rows, which can be canceled with
+// Ctrl-C.
+func (c *DBClient) exec(q string) (sql.Result, error) {
+	logger.Debug("exec: %s", q)
+	ctx, cancel := c.queryContext()
+	defer cancel()
+	stop := c.cancelOnInterrupt()
+	defer stop()
+	res, err := c.DB().ExecContext(ctx, q)
+	c.handleConnError(err)
+	return res, err
+}
+
+// queryContext returns the context of a statement, bounded by query_timeout.
+func (c *DBClient) queryContext() (context.Context, context.CancelFunc) {
 	if c.cfg.QueryTimeout > 0 {
-		ctx, cancel := context.WithTimeout(context.TODO(), c.cfg.QueryTimeout)
-		rows, err := c.DB().QueryContext(ctx, q, args...)
-		return rows, func() { cancel(); rows.Close() }, err
+		return context.WithTimeout(context.TODO(), c.cfg.QueryTimeout)
 	}
-	rows, err := c.DB().Query(q, args...)
-	return rows, func() { rows.Close() }, err
+	return context.TODO(), func() {}
 }
 
 func (c *DBClient) Query(qstr string, conds []string, order string, vals ...any) (*sql.Rows, CloseFunc, error) {
@@ -150,6 +241,107 @@ func (c *DBClient) Query(qstr string, conds []string, order string, vals ...any)
 // helpQuitExitRE is a regexp to use to match help, quit, or exit messages.
 var helpQuitExitRE = regexp.MustCompile(`(?im)^(help|quit|exit)\s*$`)
 
+const helpMessage = `You are using gsmate, the command-line interface to openGauss.
+Type:  \copyright for distribution terms
+       \? for help with gsmate commands
+       \g or terminate with semicolon to execute query
+       \q to quit`
+
+// Connect satisfies the metacmd.Handler interface. The current connection is
+// kept when the new one cannot be established.
+func (c *DBClient) Connect(params []string) error {
+	cfg := *c.cfg
+	if len(params) == 1 && config.IsConnString(params[0]) {
+		parsed, err := config.ParseConnString(params[0])
+		if err != nil {
+			return err
+		}
+		if parsed.Service != "" {
+			if err := cfg.Connection.MergeService(parsed.Service); err != nil {
+				return err
+			}
+		}
+		cfg.Connection.Merge(parsed)
+		params = nil
+	}
+	if len(params) == 1 {
+		if _, ok := c.cfg.Profile(params[0]); ok {
+			fresh, err := config.Load()
+			if err != nil {
+				return err
+			}
+			if err := fresh.ApplyProfile(params[0]); err != nil {
+				return err
+			}
+			fresh.NoPasswordPrompt = c.cfg.NoPasswordPrompt
+			keepSpecialVars(fresh, c.cfg)
+			cfg = *fresh
+			params = nil
+		}
+	}
+	if len(params) > 4 {
+		return errdef.ErrWrongNumberOfArguments
+	}
+	for i, v := range params {
+		if v == "-" || v == "" {
+			continue
+		}
+		switch i {
+		case 0:
+			cfg.DBName = v
+		case 1:
+			cfg.Username = v
+		case 2:
+			cfg.Host = v
+		case 3:
+			port, err := strconv.Atoi(v)
+			if err != nil {
+				return errors.Errorf("invalid port number: %q", v)
+			}
+			cfg.Port = port
+		}
+	}
+
+	// like psql, the password is only reused for the same server and user
+	if cfg.Password == c.cfg.Password &&
+		(cfg.Host != c.cfg.Host || cfg.Port != c.cfg.Port || cfg.Username != c.cfg.Username) {
+		cfg.Password = ""
+	}
+
+	db, n, err := connect(&cfg, c.handleNotice)
+	if err != nil {
+		return err
+	}
+	if err := c.attach(db, n); err != nil {
+		return err
+	}
+	*c.cfg = cfg
+	c.session = newSessionState()
+	c.refreshPromptPrefix()
+	if err := c.initServerInfo(); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.Out(), "You are now connected to database %q as user %q on host %q at port \"%d\" (%s).\n",
+		cfg.DBName, cfg.Username, n.addr.Host, n.addr.Port, n.role())
+	return nil
+}
+
+// refreshPromptPrefix renders the prompt prefix for the node of the
+// connection, so that $h and $p show the host it landed on.
+func (c *DBClient) refreshPromptPrefix() {
+	cfg := *c.cfg
+	cfg.Host, cfg.Port = c.node.addr.Host, c.node.addr.Port
+	c.promptPrefix = cfg.PromptPrefix()
+}
+
+// Out satisfies the metacmd.Handler interface.
+func (c *DBClient) Out() io.Writer {
+	if c.out != nil {
+		return c.out
+	}
+	return os.Stdout
+}
+
 // RunCli is the interactive client for db.
 func (c *DBClient) Run() error {
 	defer func() {
@@ -159,23 +351,89 @@ func (c *DBClient) Run() error {
 	if !c.cfg.LessChatty {
 		fmt.Printf("Server: %s\n", c.version)
 		fmt.Printf("Client: gsmate %s (%s)\n", version.Version, version.Commit)
+		fmt.Printf("Connection: %s\n", c.cfg.Address())
+		fmt.Printf("Node: %s (%s)\n", c.node.addr, c.node.role())
+		if c.sslInfo != "" {
+			fmt.Printf("SSL connection (%s)\n", c.sslInfo)
+		}
 		fmt.Println(`Type "help" for more information.`)
 		fmt.Println()
 	}
 
+	return c.loop(true)
+}
+
+// RunBatch executes the statements and meta commands read from r without
+// prompting, as for -c, -f and piped input. A statement left unterminated at
+// the end of r is executed as well. It returns the first error encountered,
+// stopping there when on_error_stop is set.
+func (c *DBClient) RunBatch(r io.Reader) error {
+	c.stmt = NewStmt(lineSource(r))
+	return c.loop(false)
+}
+
+// lineSource returns a rune source reading r line by line.
+func lineSource(r io.Reader) func() ([]rune, error) {
+	br := bufio.NewReader(r)
+	return func() ([]rune, error) {
+		line, err := br.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return nil, err
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		return []rune(line), nil
+	}
+}
+
+// loop reads and executes statements from c.stmt until the rune source is
+// exhausted or the user quits.
+func (c *DBClient) loop(interactive bool) error {
+	c.interactive = interactive
+	defer c.closeOutput()
+	var failed error
+	// fail records err, reporting whether processing has to stop
+	fail := func(err error) bool {
+		if interactive {
+			return false
+		}
+		if failed == nil {
+			failed = err
+		}
+		// a script cannot go on without its session
+		return c.cfg.OnErrorStop || isConnError(err)
+	}
+
 	for {
-		/*cmd, paramstr,*/ _, _, err := c.stmt.Next(Unquote)
+		cmd, paramstr, err := c.stmt.Next(c.unquote)
 		if err != nil {
 			if errors.Is(err, prompt.ErrQuit) {
 				return nil
 			}
+			if !interactive && errors.Is(err, io.EOF) {
+				if len(c.stmt.Buf) != 0 {
+					if err := c.execute(metacmd.Option{}); err != nil {
+						fail(err)
+					}
+				}
+				return failed
+			}
 			return err
 		}
 
 		var opt metacmd.Option
+		if cmd != "" {
+			opt, err = metacmd.Run(c, cmd, metacmd.NewArgs(paramstr, c.unquote))
+			if err != nil {
+				c.reportError(err, "")
+				if fail(err) {
+					return failed
+				}
+				continue
+			}
+		}
 
 		// help, exit, quit intercept
-		if len(c.stmt.Buf) >= 4 {
+		if interactive && len(c.stmt.Buf) >= 4 {
 			i, first := RunesLastIndex(c.stmt.Buf, '\n'), false
 			if i == -1 {
 				i, first = 0, true
@@ -185,7 +443,7 @@ func (c *DBClient) Run() error {
 				case "help":
 					s = `Use \? for help or press ctrl-C to clear the input buffer.`
 					if first {
-						s = `TODO: help message`
+						s = helpMessage
 						c.stmt.Reset(nil)
 					}
 				case "quit", "exit":
@@ -199,31 +457,111 @@ func (c *DBClient) Run() error {
 		}
 
 		if opt.Quit {
-			return nil
+			return failed
 		}
 
-		// FIXME
 		if c.stmt.Ready() || opt.Exec != metacmd.ExecNone {
-			err = c.doQuery(c.stmt.String())
-			if err != nil {
-				logger.Error("query error: %v", err)
-			} else {
-				logger.Debug("reset statement")
+			if err := c.execute(opt); err != nil && fail(err) {
+				return failed
 			}
-			c.stmt.Reset(nil)
 		}
 	}
 }
 
-func (c *DBClient) doQuery(q string, args ...any) error {
-	rows, closeFunc, err := c.query(q, args...)
+// execute runs the statement buffer, or the previous query when empty and
+// run with \g, and resets it. The output options of \g and \gx apply to this
+// statement only.
+func (c *DBClient) execute(opt metacmd.Option) error {
+	defer c.stmt.Reset(nil)
+	q, prefix := c.stmt.String(), c.stmt.Prefix
+	if strings.TrimSpace(q) == "" && opt.Exec != metacmd.ExecNone {
+		q, prefix = c.lastQuery, FindPrefix(c.lastQuery)
+	}
+	if strings.TrimSpace(q) == "" {
+		if opt.Exec == metacmd.ExecWatch {
+			c.reportError(errors.New(`\watch cannot be used with an empty query`), "")
+		}
+		return nil
+	}
+	c.lastQuery = q
+	restore, err := c.redirect(opt.Params)
+	if err != nil {
+		return err
+	}
+	defer restore()
+	switch opt.Exec {
+	case metacmd.ExecSet:
+		err = c.run(q, prefix, c.gset(opt.Params["prefix"]))
+	case metacmd.ExecExec:
+		err = c.gexec(q, prefix)
+	case metacmd.ExecCrosstab:
+		err = c.run(q, prefix, c.crosstab(opt.Crosstab))
+	case metacmd.ExecWatch:
+		err = c.watch(q, prefix, opt)
+	default:
+		err = c.run(q, prefix, c.doQuery)
+	}
+	if err != nil {
+		return err
+	}
+	logger.Debug("reset statement")
+	return nil
+}
+
+// run runs the statement q with prefix in the transaction handling of the
+// session, reporting its error.
+func (c *DBClient) run(q, prefix string, run func(q, prefix string) error) error {
+	err := c.runInTx(q, prefix, run)
+	if err != nil {
+		c.recordResult(prefix, 0, err)
+		c.reportError(err, q)
+	}
+	return err
+}
+
+// doQuery runs the statement q with prefix, rendering the rows it returns
+// or printing its command tag.
+func (c *DBClient) doQuery(q, prefix string) error {
+	start := time.Now()
+	if !returnsRows(prefix, q) {
+		res, err := c.exec(q)
+		if err != nil {
+			return err
+		}
+		n, _ := res.RowsAffected()
+		if tag := commandTag(prefix, n); tag != "" {
+			fmt.Fprintln(c.Out(), tag)
+		}
+		c.recordTiming(queryTiming{total: time.Since(start)})
+		c.recordResult(prefix, n, nil)
+		c.recordSession(q)
+		return nil
+	}
+
+	rows, closeFunc, err := c.query(q)
 	if err != nil {
 		return err
 	}
-	defer closeFunc()
 	params := config.GetPrintConfig()
-	resultSet := tblfmt.ResultSet(rows)
-	return tblfmt.EncodeAll(os.Stdout, resultSet, params)
+	resultSet := &countingRows{Rows: rows, start: start}
+	release := c.holdNotices()
+	renderStart := time.Now()
+	err = encodePaged(c.Out(), resultSet, params)
+	closeFunc()
+	end := time.Now()
+	release()
+	if err != nil {
+		c.handleConnError(err)
+		return err
+	}
+	// rows returned by a RETURNING clause
+	if isDML(prefix) {
+		fmt.Fprintln(c.Out(), commandTag(prefix, resultSet.n))
+	}
+	c.recordTiming(resultSet.timing(end.Sub(renderStart), end))
+	c.recordResult(prefix, resultSet.n, nil)
+	c.recordSession(q)
+	return nil
 }
 
 func (c *DBClient) Catalogs(f metadata.Filter) (*metadata.CatalogSet, error) {
@@ -258,12 +596,16 @@ FROM pg_catalog.pg_database d`
 func (c *DBClient) Tables(f metadata.Filter) (*metadata.TableSet, error) {
 	qstr := `SELECT n.nspname as "Schema",
   c.relname as "Name",
-  CASE c.relkind WHEN 'r' THEN 'table' WHEN 'v' THEN 'view' WHEN 'm' THEN 'materialized view' WHEN 'i' THEN 'index' WHEN 'S' THEN 'sequence' WHEN 's' THEN 'special' WHEN 'f' THEN 'foreign table' WHEN 'p' THEN 'partitioned table' WHEN 'I' THEN 'partitioned index' ELSE 'unknown' END as "Type",
+  CASE c.relkind WHEN 'r' THEN 'table' WHEN 'v' THEN 'view' WHEN 'm' THEN 'materialized view' WHEN 'i' THEN 'index' WHEN 'S' THEN 'sequence' WHEN 'L' THEN 'large sequence' WHEN 's' THEN 'special' WHEN 'f' THEN 'foreign table' WHEN 'p' THEN 'partitioned table' WHEN 'I' THEN 'global index' ELSE 'unknown' END as "Type",
   COALESCE((c.reltuples / NULLIF(c.relpages, 0)) * (pg_catalog.pg_relation_size(c.oid) / current_setting('block_size')::int), 0)::bigint as "Rows",
   pg_catalog.pg_size_pretty(pg_catalog.pg_table_size(c.oid)) as "Size",
-  COALESCE(pg_catalog.obj_description(c.oid, 'pg_class'), '') as "Description"
+  COALESCE(pg_catalog.obj_description(c.oid, 'pg_class'), '') as "Description",
+  pg_catalog.pg_get_userbyid(c.relowner) as "Owner",
+  COALESCE(c2.relname, '') as "Table"
 FROM pg_catalog.pg_class c
      LEFT JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+     LEFT JOIN pg_catalog.pg_index i ON i.indexrelid = c.oid
+     LEFT JOIN pg_catalog.pg_class c2 ON c2.oid = i.indrelid
 `
 	conds := []string{"n.nspname !~ '^pg_toast' AND c.relkind != 'c'"}
 	vals := []interface{}{}
@@ -284,9 +626,12 @@ FROM pg_catalog.pg_class c
 	if len(f.Types) != 0 {
 		tableTypes := map[string][]rune{
 			"TABLE":             {'r', 'p', 's', 'f'},
+			"BASE TABLE":        {'r', 'p'},
 			"VIEW":              {'v'},
 			"MATERIALIZED VIEW": {'m'},
-			"SEQUENCE":          {'S'},
+			"SEQUENCE":          {'S', 'L'},
+			"INDEX":             {'i', 'I'},
+			"FOREIGN TABLE":     {'f'},
 		}
 		pholders := []string{"''"}
 		for _, t := range f.Types {
@@ -309,7 +654,7 @@ FROM pg_catalog.pg_class c
 	results := []metadata.Table{}
 	for rows.Next() {
 		rec := metadata.Table{}
-		err = rows.Scan(&rec.Schema, &rec.Name, &rec.Type, &rec.Rows, &rec.Size, &rec.Comment)
+		err = rows.Scan(&rec.Schema, &rec.Name, &rec.Type, &rec.Rows, &rec.Size, &rec.Comment, &rec.Owner, &rec.Parent)
 		if err != nil {
 			return nil, err
 		}
@@ -320,7 +665,3 @@ FROM pg_catalog.pg_class c
 	}
 	return metadata.NewTableSet(results), nil
 }
-
-func (c *DBClient) Functions(f metadata.Filter) (*metadata.FunctionSet, error) {
-	return nil, errdef.ErrNotSupported
-}
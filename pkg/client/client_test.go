@@ -0,0 +1,391 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/tls"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"gsmate/config"
+	"gsmate/pkg/client/metacmd"
+
+	pq "gitee.com/opengauss/openGauss-connector-go-pq"
+)
+
+func TestLineSource(t *testing.T) {
+	tests := []struct {
+		s   string
+		exp []string
+	}{
+		{"", nil},
+		{"select 1;", []string{"select 1;"}},
+		{"select 1;\n", []string{"select 1;"}},
+		{"select\r\n1;\n\n\\dt\n", []string{"select", "1;", "", `\dt`}},
+	}
+	for i, test := range tests {
+		f := lineSource(strings.NewReader(test.s))
+		var lines []string
+		for {
+			r, err := f()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("test %d expected no error, got: %v", i, err)
+			}
+			lines = append(lines, string(r))
+		}
+		if strings.Join(lines, "|") != strings.Join(test.exp, "|") || len(lines) != len(test.exp) {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, lines)
+		}
+	}
+}
+
+func TestSessionMatches(t *testing.T) {
+	tests := []struct {
+		attrs            string
+		primary, standby bool
+	}{
+		{"", true, true},
+		{"any", true, true},
+		{"read-write", true, false},
+		{"primary", true, false},
+		{"read-only", false, true},
+		{"standby", false, true},
+		{"prefer-standby", false, true},
+	}
+	for _, test := range tests {
+		if m := sessionMatches(test.attrs, false); m != test.primary {
+			t.Errorf("%q expected primary match %t, got: %t", test.attrs, test.primary, m)
+		}
+		if m := sessionMatches(test.attrs, true); m != test.standby {
+			t.Errorf("%q expected standby match %t, got: %t", test.attrs, test.standby, m)
+		}
+	}
+}
+
+func TestAttemptError(t *testing.T) {
+	errSSL := errors.New("pq: SSL is not enabled on the server")
+	errAuth := &pq.Error{Code: errInvalidPassword}
+	errRefused := errors.New("dial tcp: connection refused")
+	tests := []struct {
+		prev, err, exp error
+	}{
+		{nil, errSSL, errSSL},
+		// sslmode=prefer, the server has no SSL
+		{errSSL, errAuth, errAuth},
+		// sslmode=allow, SSL fails after the server refused the password
+		{errAuth, errSSL, errAuth},
+		{errSSL, errRefused, errRefused},
+	}
+	for i, test := range tests {
+		if err := attemptError(test.prev, test.err); err != test.exp {
+			t.Errorf("test %d expected %v, got: %v", i, test.exp, err)
+		}
+	}
+	if !isAuthError(attemptError(errSSL, errAuth)) {
+		t.Error("expected an authentication error")
+	}
+}
+
+func TestTLSState(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	type driverConn struct {
+		name string
+		c    net.Conn
+	}
+	if _, ok := tlsState(&driverConn{c: client}); ok {
+		t.Error("expected no TLS state without SSL")
+	}
+	if _, ok := tlsState(driverConn{c: tls.Client(client, &tls.Config{})}); ok {
+		t.Error("expected no TLS state of a connection value")
+	}
+	if _, ok := tlsState(&driverConn{c: tls.Client(client, &tls.Config{})}); !ok {
+		t.Error("expected the TLS state of an SSL connection")
+	}
+
+	state := tls.ConnectionState{Version: tls.VersionTLS13, CipherSuite: tls.TLS_AES_256_GCM_SHA384}
+	if s, exp := sslInfo(state), "protocol: TLSv1.3, cipher: TLS_AES_256_GCM_SHA384"; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+}
+
+func TestSessionState(t *testing.T) {
+	s := newSessionState()
+	for _, q := range []string{
+		"SET search_path TO app, public",
+		"set statement_timeout = 1000",
+		"SET LOCAL work_mem = '64MB'",
+		"SET TRANSACTION ISOLATION LEVEL SERIALIZABLE",
+		"SET SESSION datestyle TO ISO",
+		"SET SCHEMA 'other'",
+		"RESET datestyle",
+		"SELECT 1",
+	} {
+		s.record(q, false)
+	}
+	exp := []string{"SET SCHEMA 'other'", "set statement_timeout = 1000"}
+	if got := s.statements(); strings.Join(got, "|") != strings.Join(exp, "|") {
+		t.Errorf("expected %q, got: %q", exp, got)
+	}
+	s.record("RESET ALL;", false)
+	if got := s.statements(); len(got) != 0 {
+		t.Errorf("expected no statements, got: %q", got)
+	}
+}
+
+func TestSessionStateTx(t *testing.T) {
+	idle, active, failed := metacmd.TxIdle, metacmd.TxActive, metacmd.TxFailed
+	type step struct {
+		q        string
+		cur, nxt metacmd.TxStatus
+	}
+	tests := []struct {
+		steps []step
+		exp   []string
+	}{
+		{
+			[]step{{"BEGIN", idle, active}, {"SET work_mem = '64MB'", active, active}, {"ROLLBACK", active, idle}},
+			nil,
+		},
+		{
+			[]step{{"BEGIN", idle, active}, {"SET work_mem = '64MB'", active, active}, {"COMMIT", active, idle}},
+			[]string{"SET work_mem = '64MB'"},
+		},
+		{
+			// COMMIT of a failed block rolls it back
+			[]step{{"BEGIN", idle, active}, {"SET work_mem = '64MB'", active, active}, {"COMMIT", failed, idle}},
+			nil,
+		},
+		{
+			[]step{
+				{"BEGIN", idle, active},
+				{"SET datestyle TO ISO", active, active},
+				{"SAVEPOINT sp", active, active},
+				{"SET work_mem = '64MB'", active, active},
+				{"ROLLBACK TO SAVEPOINT SP", failed, active},
+				{"END", active, idle},
+			},
+			[]string{"SET datestyle TO ISO"},
+		},
+		{
+			[]step{{"SET datestyle TO ISO", idle, idle}, {"BEGIN", idle, active}, {"RESET datestyle", active, active}, {"ABORT", active, idle}},
+			[]string{"SET datestyle TO ISO"},
+		},
+	}
+	for i, test := range tests {
+		s := newSessionState()
+		for _, st := range test.steps {
+			s.record(st.q, st.cur != idle)
+			s.trackTx(st.q, FindPrefix(st.q), st.cur, st.nxt)
+		}
+		if got := s.statements(); strings.Join(got, "|") != strings.Join(test.exp, "|") {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, got)
+		}
+	}
+}
+
+func TestIsConnError(t *testing.T) {
+	tests := []struct {
+		err error
+		exp bool
+	}{
+		{io.EOF, true},
+		{driver.ErrBadConn, true},
+		{&pq.Error{Code: "57P01"}, true},
+		{&pq.Error{Code: "08006"}, true},
+		{&pq.Error{Code: "42P01"}, false},
+		{errors.New("syntax error"), false},
+	}
+	for i, test := range tests {
+		if b := isConnError(test.err); b != test.exp {
+			t.Errorf("test %d expected %t, got: %t", i, test.exp, b)
+		}
+	}
+}
+
+func TestNextTxStatus(t *testing.T) {
+	idle, active, failed := metacmd.TxIdle, metacmd.TxActive, metacmd.TxFailed
+	errQuery := errors.New("query failed")
+	tests := []struct {
+		cur metacmd.TxStatus
+		q   string
+		err error
+		exp metacmd.TxStatus
+	}{
+		{idle, "begin;", nil, active},
+		{idle, "START TRANSACTION ISOLATION LEVEL SERIALIZABLE", nil, active},
+		{idle, "select 1", errQuery, idle},
+		{active, "insert into t values (1)", nil, active},
+		{active, "insert into t values (1)", errQuery, failed},
+		{failed, "select 1", errQuery, failed},
+		{failed, "rollback to savepoint sp1", nil, active},
+		{failed, "ROLLBACK", nil, idle},
+		{active, "commit", nil, idle},
+		{active, "end", nil, idle},
+		{active, "abort", nil, idle},
+		{active, "PREPARE TRANSACTION 'tx1'", nil, idle},
+		{idle, "COMMIT PREPARED 'tx1'", nil, idle},
+		{idle, "/* comment */ BEGIN", nil, active},
+	}
+	for i, test := range tests {
+		if s := nextTxStatus(test.cur, FindPrefix(test.q), test.err); s != test.exp {
+			t.Errorf("test %d expected %s, got: %s", i, test.exp, s)
+		}
+	}
+}
+
+func TestNoImplicitBegin(t *testing.T) {
+	tests := []struct {
+		q   string
+		exp bool
+	}{
+		{"select 1", false},
+		{"insert into t values (1)", false},
+		{"begin", true},
+		{"commit", true},
+		{"vacuum analyze t", true},
+		{"cluster", true},
+		{"cluster t using t_idx", false},
+		{"create database db", true},
+		{"create index concurrently i on t (a)", true},
+		{"create unique index concurrently i on t (a)", true},
+		{"create index i on t (a)", false},
+		{"reindex database db", true},
+		{"discard all", true},
+		{"prepare transaction 'tx1'", true},
+		{"prepare stmt as select 1", false},
+	}
+	for i, test := range tests {
+		if b := noImplicitBegin(FindPrefix(test.q)); b != test.exp {
+			t.Errorf("test %d %q expected %t, got: %t", i, test.q, test.exp, b)
+		}
+	}
+}
+
+func TestSetVar(t *testing.T) {
+	c := &DBClient{cfg: &config.Config{Autocommit: true, OnErrorRollback: "off"}}
+	if err := c.SetVar("AUTOCOMMIT", "off"); err != nil || c.cfg.Autocommit {
+		t.Errorf("expected autocommit off, got: %t (%v)", c.cfg.Autocommit, err)
+	}
+	if err := c.SetVar("ON_ERROR_ROLLBACK", "interactive"); err != nil || c.cfg.OnErrorRollback != "interactive" {
+		t.Errorf("expected on_error_rollback interactive, got: %q (%v)", c.cfg.OnErrorRollback, err)
+	}
+	if err := c.SetVar("ON_ERROR_ROLLBACK", "true"); err != nil || c.cfg.OnErrorRollback != "on" {
+		t.Errorf("expected on_error_rollback on, got: %q (%v)", c.cfg.OnErrorRollback, err)
+	}
+	for _, v := range [][2]string{{"AUTOCOMMIT", "maybe"}, {"ON_ERROR_ROLLBACK", "sometimes"}, {"bad-name", "x"}} {
+		if err := c.SetVar(v[0], v[1]); err == nil {
+			t.Errorf("expected error setting %s to %q", v[0], v[1])
+		}
+	}
+	if err := c.SetVar("foo", "bar"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	vars := c.Vars()
+	if vars["foo"] != "bar" || vars["AUTOCOMMIT"] != "off" || vars["ON_ERROR_ROLLBACK"] != "on" {
+		t.Errorf("unexpected vars: %v", vars)
+	}
+}
+
+func TestKeepSpecialVars(t *testing.T) {
+	session := &config.Config{OnErrorStop: true, OnErrorRollback: "interactive", Verbosity: "terse"}
+	cfg := &config.Config{Autocommit: true, OnErrorRollback: "off", Verbosity: "default"}
+	keepSpecialVars(cfg, session)
+	for name, v := range specialVars {
+		if got, exp := v.get(cfg), v.get(session); got != exp {
+			t.Errorf("%s expected %q, got: %q", name, exp, got)
+		}
+	}
+	if !cfg.OnErrorStop {
+		t.Error("expected ON_ERROR_STOP to be kept")
+	}
+
+	keepSpecialVars(cfg, &config.Config{Timing: true})
+	if !cfg.Timing {
+		t.Error("expected TIMING to be kept")
+	}
+}
+
+func TestGsetVar(t *testing.T) {
+	c := &DBClient{cfg: &config.Config{Autocommit: true}, vars: map[string]string{"old": "x"}}
+	c.gsetVar("p_id", sql.NullString{String: "42", Valid: true})
+	c.gsetVar("old", sql.NullString{})
+	c.gsetVar("AUTOCOMMIT", sql.NullString{String: "off", Valid: true})
+	c.gsetVar("DBNAME", sql.NullString{String: "other", Valid: true})
+	if c.vars["p_id"] != "42" {
+		t.Errorf("expected p_id 42, got %q", c.vars["p_id"])
+	}
+	if _, ok := c.vars["old"]; ok {
+		t.Errorf("expected old to be unset by NULL")
+	}
+	if !c.cfg.Autocommit {
+		t.Errorf("expected AUTOCOMMIT to be left alone")
+	}
+}
+
+func TestInterpolation(t *testing.T) {
+	c := &DBClient{cfg: &config.Config{Connection: config.Connection{DBName: "postgres"}}}
+	for _, v := range [][2]string{{"name", "O'Brien"}, {"path", `C:\tmp`}, {"tbl", `my "t"`}} {
+		if err := c.SetVar(v[0], v[1]); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+	if err := c.SetVar("DBNAME", "other"); err == nil {
+		t.Errorf("expected error setting DBNAME")
+	}
+	if err := c.UnsetVar("AUTOCOMMIT"); err == nil {
+		t.Errorf("expected error unsetting AUTOCOMMIT")
+	}
+	tests := []struct {
+		s   string
+		ok  bool
+		exp string
+	}{
+		{"name", true, "O'Brien"},
+		{"'name'", true, "'O''Brien'"},
+		{"'path'", true, `E'C:\\tmp'`},
+		{`"tbl"`, true, `"my ""t"""`},
+		{"DBNAME", true, "postgres"},
+		{"undefined", false, "undefined"},
+	}
+	for i, test := range tests {
+		ok, z, err := c.unquote(test.s, true)
+		if err != nil || ok != test.ok || z != test.exp {
+			t.Errorf("test %d expected %t %q, got: %t %q (%v)", i, test.ok, test.exp, ok, z, err)
+		}
+	}
+	b := NewStmt(lineSource(strings.NewReader("select :'name', :\"tbl\";")))
+	if _, _, err := b.Next(c.unquote); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if exp := `select 'O''Brien', "my ""t""";`; b.String() != exp {
+		t.Errorf("expected %q, got: %q", exp, b.String())
+	}
+	if err := c.UnsetVar("name"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if ok, _, _ := c.unquote("name", true); ok {
+		t.Errorf("expected name to be unset")
+	}
+}
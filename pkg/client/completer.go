@@ -65,7 +65,7 @@ func (c *CmdCompleter) complete(previousWords []string, text []rune) []prompt.Su
 	if len(text) > 0 {
 		if len(previousWords) == 0 && text[0] == '\\' {
 			/* If current word is a backslash command, offer completions for that */
-			return c.completeFromListCase(MATCH_CASE, text, backslashCommands...)
+			return c.completeFromListCase(MATCH_CASE, text, getBackslashCmdSuggests()...)
 		}
 		if text[0] == ':' {
 			if len(text) == 1 || text[1] == ':' {
@@ -180,65 +180,60 @@ func (c *CmdCompleter) complete(previousWords []string, text []rune) []prompt.Su
 	if TailMatches(MATCH_CASE, previousWords, `\copy`, `*`, `*`) {
 		return nil
 	}
-	// if TailMatches(MATCH_CASE, previousWords, `\da*`) {
-	// 	return c.completeWithFunctions(text, []string{"AGGREGATE"})
-	// }
-	// if TailMatches(MATCH_CASE, previousWords, `\df*`) {
-	// 	return c.completeWithFunctions(text, []string{})
-	// }
-	// if TailMatches(MATCH_CASE, previousWords, `\di*`) {
-	// 	return c.completeWithIndexes(text)
-	// }
-	// if TailMatches(MATCH_CASE, previousWords, `\dn*`) {
-	// 	return c.completeWithSchemas(text)
-	// }
-	// if TailMatches(MATCH_CASE, previousWords, `\ds*`) {
-	// 	return c.completeWithSequences(text)
-	// }
-	// if TailMatches(MATCH_CASE, previousWords, `\dt*`) {
-	// 	return c.completeWithTables(text, []string{"TABLE", "BASE TABLE", "SYSTEM TABLE", "SYNONYM", "LOCAL TEMPORARY", "GLOBAL TEMPORARY"})
-	// }
-	// if TailMatches(MATCH_CASE, previousWords, `\dv*`) {
-	// 	return c.completeWithTables(text, []string{"VIEW", "SYSTEM VIEW"})
-	// }
-	// if TailMatches(MATCH_CASE, previousWords, `\dm*`) {
-	// 	return c.completeWithTables(text, []string{"MATERIALIZED VIEW"})
-	// }
-	// if TailMatches(MATCH_CASE, previousWords, `\d*`) {
-	// 	return c.completeWithSelectables(text)
-	// }
+	if TailMatches(MATCH_CASE, previousWords, `\c|\connect`) {
+		profiles := c.client.cfg.Profiles()
+		names := make([]string, 0, len(profiles))
+		for _, p := range profiles {
+			names = append(names, p.Name)
+		}
+		return c.completeFromStrList(text, names...)
+	}
+	if TailMatches(MATCH_CASE, previousWords, `\da*`) {
+		return c.completeWithFunctions(text, []string{"AGGREGATE"})
+	}
+	if TailMatches(MATCH_CASE, previousWords, `\df*`) {
+		return c.completeWithFunctions(text, []string{})
+	}
+	if TailMatches(MATCH_CASE, previousWords, `\di*`) {
+		return c.completeWithIndexes(text)
+	}
+	if TailMatches(MATCH_CASE, previousWords, `\dn*`) {
+		return c.completeWithSchemas(text)
+	}
+	if TailMatches(MATCH_CASE, previousWords, `\ds*`) {
+		return c.completeWithSequences(text)
+	}
+	if TailMatches(MATCH_CASE, previousWords, `\dt*`) {
+		return c.completeWithTables(text, []string{"BASE TABLE"})
+	}
+	if TailMatches(MATCH_CASE, previousWords, `\dv*`) {
+		return c.completeWithTables(text, []string{"VIEW"})
+	}
+	if TailMatches(MATCH_CASE, previousWords, `\dm*`) {
+		return c.completeWithTables(text, []string{"MATERIALIZED VIEW"})
+	}
+	if TailMatches(MATCH_CASE, previousWords, `\dE*`) {
+		return c.completeWithTables(text, []string{"FOREIGN TABLE"})
+	}
+	if TailMatches(MATCH_CASE, previousWords, `\d*`) {
+		return c.completeWithSelectables(text)
+	}
 	// if TailMatches(MATCH_CASE, previousWords, `\l*`) ||
 	// 	TailMatches(MATCH_CASE, previousWords, `\lo*`) {
 	// 	return c.completeWithCatalogs(text)
 	// }
-	// if TailMatches(MATCH_CASE, previousWords, `\pset`) {
-	// 	return c.completeFromStrList(text, `border`, `columns`, `expanded`, `fieldsep`, `fieldsep_zero`,
-	// 		`footer`, `format`, `linestyle`, `null`, `numericlocale`, `pager`, `pager_min_lines`,
-	// 		`recordsep`, `recordsep_zero`, `tableattr`, `title`, `title`, `tuples_only`,
-	// 		`unicode_border_linestyle`, `unicode_column_linestyle`, `unicode_header_linestyle`)
-	// }
-	// if TailMatches(MATCH_CASE, previousWords, `\pset`, `expanded`) {
-	// 	return c.completeFromStrList(text, "auto", "on", "off")
-	// }
-	// if TailMatches(MATCH_CASE, previousWords, `\pset`, `pager`) {
-	// 	return c.completeFromStrList(text, "always", "on", "off")
-	// }
-	// if TailMatches(MATCH_CASE, previousWords, `\pset`, `fieldsep_zero|footer|numericlocale|pager|recordsep_zero|tuples_only`) {
-	// 	return c.completeFromStrList(text, "on", "off")
-	// }
-	// if TailMatches(MATCH_CASE, previousWords, `\pset`, `format`) {
-	// 	return c.completeFromStrList(text, "unaligned", "aligned", "wrapped", "html", "asciidoc", "latex", "latex-longtable", "troff-ms", "csv", "json", "vertical")
-	// }
-	// if TailMatches(MATCH_CASE, previousWords, `\pset`, `linestyle`) {
-	// 	return c.completeFromStrList(text, "ascii", "old-ascii", "unicode")
-	// }
-	// if TailMatches(MATCH_CASE, previousWords, `\pset`, `unicode_border_linestyle|unicode_column_linestyle|unicode_header_linestyle`) {
-	// 	return c.completeFromStrList(text, "single", "double")
-	// }
-	// if TailMatches(MATCH_CASE, previousWords, `\pset`, `*`) ||
-	// 	TailMatches(MATCH_CASE, previousWords, `\pset`, `*`, `*`) {
-	// 	return nil
-	// }
+	if TailMatches(MATCH_CASE, previousWords, `\pset`) {
+		return c.completeFromStrList(text, config.PrintOptions()...)
+	}
+	if TailMatches(MATCH_CASE, previousWords, `\pset`, `*`) {
+		return c.completeFromStrList(text, config.PrintOptionValues(previousWords[0])...)
+	}
+	if TailMatches(MATCH_CASE, previousWords, `\x`) {
+		return c.completeFromStrList(text, "auto", "on", "off")
+	}
+	if TailMatches(MATCH_CASE, previousWords, `\t`) {
+		return c.completeFromStrList(text, "on", "off")
+	}
 	if TailMatches(MATCH_CASE, previousWords, `\?`) {
 		return c.completeFromStrList(text, "commands", "options", "variables")
 	}
@@ -442,6 +437,86 @@ func (c *CmdCompleter) completeWithUpdatables(text []rune) []prompt.Suggest {
 	return c.completeFromStrList(text, names...)
 }
 
+func (c *CmdCompleter) completeWithSelectables(text []rune) []prompt.Suggest {
+	return c.completeWithTables(text, []string{"TABLE", "VIEW", "MATERIALIZED VIEW", "SEQUENCE"})
+}
+
+func (c *CmdCompleter) completeWithTables(text []rune, types []string) []prompt.Suggest {
+	filter := parseIdentifier(string(text))
+	filter.Types = types
+	names := c.getNames(
+		func() (iterator, error) {
+			return c.client.Tables(filter)
+		},
+		func(res interface{}) string {
+			t := res.(*metadata.TableSet).Get()
+			return qualifiedIdentifier(filter, t.Schema, t.Name)
+		},
+	)
+	sort.Strings(names)
+	return c.completeFromStrList(text, names...)
+}
+
+func (c *CmdCompleter) completeWithIndexes(text []rune) []prompt.Suggest {
+	filter := parseIdentifier(string(text))
+	names := c.getNames(
+		func() (iterator, error) {
+			return c.client.Indexes(filter)
+		},
+		func(res interface{}) string {
+			i := res.(*metadata.IndexSet).Get()
+			return qualifiedIdentifier(filter, i.Schema, i.Name)
+		},
+	)
+	sort.Strings(names)
+	return c.completeFromStrList(text, names...)
+}
+
+func (c *CmdCompleter) completeWithSequences(text []rune) []prompt.Suggest {
+	filter := parseIdentifier(string(text))
+	names := c.getNames(
+		func() (iterator, error) {
+			return c.client.Sequences(filter)
+		},
+		func(res interface{}) string {
+			s := res.(*metadata.SequenceSet).Get()
+			return qualifiedIdentifier(filter, s.Schema, s.Name)
+		},
+	)
+	sort.Strings(names)
+	return c.completeFromStrList(text, names...)
+}
+
+func (c *CmdCompleter) completeWithFunctions(text []rune, types []string) []prompt.Suggest {
+	filter := parseIdentifier(string(text))
+	filter.Types = types
+	names := c.getNames(
+		func() (iterator, error) {
+			return c.client.Functions(filter)
+		},
+		func(res interface{}) string {
+			f := res.(*metadata.FunctionSet).Get()
+			return qualifiedIdentifier(filter, f.Schema, f.Name)
+		},
+	)
+	sort.Strings(names)
+	return c.completeFromStrList(text, names...)
+}
+
+func (c *CmdCompleter) completeWithSchemas(text []rune) []prompt.Suggest {
+	filter := metadata.Filter{Name: string(text) + "%"}
+	names := c.getNames(
+		func() (iterator, error) {
+			return c.client.Schemas(filter)
+		},
+		func(res interface{}) string {
+			return res.(*metadata.SchemaSet).Get().Schema
+		},
+	)
+	sort.Strings(names)
+	return c.completeFromStrList(text, names...)
+}
+
 type iterator interface {
 	Next() bool
 	Close() error
@@ -0,0 +1,266 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"database/sql"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gsmate/config"
+	"gsmate/internal/errdef"
+
+	"github.com/pkg/errors"
+)
+
+// crosstabMaxColumns is the column limit of a crosstab, as for a table.
+const crosstabMaxColumns = 1600
+
+// crosstabResult is a pivoted result, rendered with tblfmt.
+type crosstabResult struct {
+	columns []string
+	rows    [][]interface{}
+	current int
+}
+
+// Next satisfies the tblfmt.ResultSet interface.
+func (r *crosstabResult) Next() bool {
+	r.current++
+	return r.current <= len(r.rows)
+}
+
+// Scan satisfies the tblfmt.ResultSet interface.
+func (r *crosstabResult) Scan(dest ...interface{}) error {
+	row := r.rows[r.current-1]
+	if len(row) != len(dest) {
+		return errdef.ErrWrongNumberOfArguments
+	}
+	for i, d := range dest {
+		p := d.(*interface{})
+		*p = row[i]
+	}
+	return nil
+}
+
+// Columns satisfies the tblfmt.ResultSet interface.
+func (r *crosstabResult) Columns() ([]string, error) {
+	return r.columns, nil
+}
+
+// Close satisfies the tblfmt.ResultSet interface.
+func (r *crosstabResult) Close() error {
+	return nil
+}
+
+// Err satisfies the tblfmt.ResultSet interface.
+func (r *crosstabResult) Err() error {
+	return nil
+}
+
+// NextResultSet satisfies the tblfmt.ResultSet interface.
+func (r *crosstabResult) NextResultSet() bool {
+	return false
+}
+
+// crosstab returns the func running a query as \crosstabview, rendering its
+// result pivoted as set by args, the optional colV, colH, colD and sortcolH.
+func (c *DBClient) crosstab(args []string) func(q, prefix string) error {
+	return func(q, prefix string) error {
+		start := time.Now()
+		rows, closeFunc, err := c.query(q)
+		if err != nil {
+			return err
+		}
+		cols, err := rows.Columns()
+		if err != nil {
+			closeFunc()
+			return err
+		}
+		var vals [][]sql.NullString
+		for {
+			row, err := scanRow(rows, len(cols))
+			if err != nil {
+				closeFunc()
+				return err
+			}
+			if row == nil {
+				break
+			}
+			vals = append(vals, row)
+		}
+		closeFunc()
+		params := config.GetPrintConfig()
+		res, err := pivot(cols, vals, args, params["null"])
+		if err != nil {
+			return errors.Wrap(err, `\crosstabview`)
+		}
+		if err := encodePaged(c.Out(), res, params); err != nil {
+			return err
+		}
+		c.recordTiming(queryTiming{total: time.Since(start)})
+		c.recordResult(prefix, int64(len(vals)), nil)
+		return nil
+	}
+}
+
+// pivot builds the crosstab of the result rows with columns cols: the
+// distinct values of colV make the rows and the ones of colH the columns, in
+// order of appearance or by the integer value of sortcolH, and the values of
+// colD fill the cells. args are the column numbers or names of colV, colH,
+// colD and sortcolH, colV and colH defaulting to the first two columns and
+// colD to the remaining one of a three column result. A NULL horizontal
+// header is named after null.
+func pivot(cols []string, rows [][]sql.NullString, args []string, null string) (*crosstabResult, error) {
+	if len(cols) < 3 {
+		return nil, errors.New("query must return at least three columns")
+	}
+	colV, colH, colD, sortH := 0, 1, -1, -1
+	var err error
+	if len(args) > 0 && args[0] != "" {
+		if colV, err = crosstabColumn(cols, args[0]); err != nil {
+			return nil, err
+		}
+	}
+	if len(args) > 1 && args[1] != "" {
+		if colH, err = crosstabColumn(cols, args[1]); err != nil {
+			return nil, err
+		}
+	}
+	if colV == colH {
+		return nil, errors.New("vertical and horizontal headers must be different columns")
+	}
+	if len(args) > 2 && args[2] != "" {
+		if colD, err = crosstabColumn(cols, args[2]); err != nil {
+			return nil, err
+		}
+	} else {
+		if len(cols) != 3 {
+			return nil, errors.New("data column must be specified when query returns more than three columns")
+		}
+		colD = 3 - colV - colH
+	}
+	if len(args) > 3 && args[3] != "" {
+		if sortH, err = crosstabColumn(cols, args[3]); err != nil {
+			return nil, err
+		}
+	}
+
+	// the distinct headers in order of appearance
+	type header struct {
+		value sql.NullString
+		sort  int
+		// null sort values come first
+		hasSort bool
+	}
+	var vHeaders, hHeaders []header
+	vIndex, hIndex := map[sql.NullString]int{}, map[sql.NullString]int{}
+	for _, row := range rows {
+		if _, ok := vIndex[row[colV]]; !ok {
+			vIndex[row[colV]] = len(vHeaders)
+			vHeaders = append(vHeaders, header{value: row[colV]})
+		}
+		if _, ok := hIndex[row[colH]]; ok {
+			continue
+		}
+		h := header{value: row[colH]}
+		if sortH != -1 && row[sortH].Valid {
+			n, err := strconv.Atoi(strings.TrimSpace(row[sortH].String))
+			if err != nil {
+				return nil, errors.Errorf("invalid sort value %q of column %q, must be an integer", row[sortH].String, cols[sortH])
+			}
+			h.sort, h.hasSort = n, true
+		}
+		hIndex[row[colH]] = len(hHeaders)
+		hHeaders = append(hHeaders, h)
+		if len(hHeaders)+1 > crosstabMaxColumns {
+			return nil, errors.Errorf("maximum number of columns (%d) exceeded", crosstabMaxColumns)
+		}
+	}
+	if sortH != -1 {
+		sort.SliceStable(hHeaders, func(i, j int) bool {
+			a, b := hHeaders[i], hHeaders[j]
+			if a.hasSort != b.hasSort {
+				return !a.hasSort
+			}
+			return a.sort < b.sort
+		})
+		for i, h := range hHeaders {
+			hIndex[h.value] = i
+		}
+	}
+
+	res := &crosstabResult{
+		columns: make([]string, 0, len(hHeaders)+1),
+		rows:    make([][]interface{}, len(vHeaders)),
+	}
+	res.columns = append(res.columns, cols[colV])
+	for _, h := range hHeaders {
+		name := null
+		if h.value.Valid {
+			name = h.value.String
+		}
+		res.columns = append(res.columns, name)
+	}
+	for i, h := range vHeaders {
+		res.rows[i] = make([]interface{}, len(hHeaders)+1)
+		if h.value.Valid {
+			res.rows[i][0] = h.value.String
+		}
+	}
+	filled := make(map[[2]int]bool, len(rows))
+	for _, row := range rows {
+		i, j := vIndex[row[colV]], hIndex[row[colH]]
+		if filled[[2]int{i, j}] {
+			return nil, errors.Errorf("query result contains multiple data values for row %q, column %q",
+				vHeaders[i].value.String, res.columns[j+1])
+		}
+		filled[[2]int{i, j}] = true
+		if row[colD].Valid {
+			res.rows[i][j+1] = row[colD].String
+		}
+	}
+	return res, nil
+}
+
+// crosstabColumn returns the index of the column given by its number from 1,
+// or its name, case folded unless double quoted.
+func crosstabColumn(cols []string, s string) (int, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 1 || n > len(cols) {
+			return 0, errors.Errorf("column number %d is out of range 1..%d", n, len(cols))
+		}
+		return n - 1, nil
+	}
+	name := strings.ToLower(s)
+	if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+		name = strings.ReplaceAll(s[1:len(s)-1], `""`, `"`)
+	}
+	found := -1
+	for i, col := range cols {
+		if col != name {
+			continue
+		}
+		if found != -1 {
+			return 0, errors.Errorf("ambiguous column name: %q", s)
+		}
+		found = i
+	}
+	if found == -1 {
+		return 0, errors.Errorf("column name not found: %q", s)
+	}
+	return found, nil
+}
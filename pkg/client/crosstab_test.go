@@ -0,0 +1,124 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// crosstabRows builds result rows from "|" separated values, "NULL" being
+// NULL.
+func crosstabRows(rows ...string) [][]sql.NullString {
+	var vals [][]sql.NullString
+	for _, row := range rows {
+		var v []sql.NullString
+		for _, s := range strings.Split(row, "|") {
+			v = append(v, sql.NullString{String: s, Valid: s != "NULL"})
+		}
+		vals = append(vals, v)
+	}
+	return vals
+}
+
+func TestPivot(t *testing.T) {
+	cols := []string{"region", "month", "total", "month_no"}
+	rows := crosstabRows(
+		"east|mar|10|3",
+		"west|jan|20|1",
+		"east|jan|30|1",
+		"west|feb|NULL|2",
+	)
+	tests := []struct {
+		args    []string
+		columns []string
+		rows    [][]interface{}
+	}{
+		{
+			[]string{"1", "2", "3"},
+			[]string{"region", "mar", "jan", "feb"},
+			[][]interface{}{{"east", "10", "30", nil}, {"west", nil, "20", nil}},
+		},
+		{
+			[]string{"region", "month", "total", "month_no"},
+			[]string{"region", "jan", "feb", "mar"},
+			[][]interface{}{{"east", "30", nil, "10"}, {"west", "20", nil, nil}},
+		},
+		{
+			[]string{"month", `"region"`, "TOTAL"},
+			[]string{"month", "east", "west"},
+			[][]interface{}{{"mar", "10", nil}, {"jan", "30", "20"}, {"feb", nil, nil}},
+		},
+	}
+	for _, test := range tests {
+		res, err := pivot(cols, rows, test.args, "")
+		if err != nil {
+			t.Fatalf("%v: %v", test.args, err)
+		}
+		if !reflect.DeepEqual(res.columns, test.columns) {
+			t.Errorf("%v: expected columns %v, got %v", test.args, test.columns, res.columns)
+		}
+		if !reflect.DeepEqual(res.rows, test.rows) {
+			t.Errorf("%v: expected rows %v, got %v", test.args, test.rows, res.rows)
+		}
+	}
+}
+
+func TestPivotDefaults(t *testing.T) {
+	res, err := pivot([]string{"a", "b", "c"}, crosstabRows("x|NULL|1", "y|p|2"), nil, "(null)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := []string{"a", "(null)", "p"}; !reflect.DeepEqual(res.columns, exp) {
+		t.Errorf("expected columns %v, got %v", exp, res.columns)
+	}
+	var n int
+	for res.Next() {
+		var v, h1, h2 interface{}
+		if err := res.Scan(&v, &h1, &h2); err != nil {
+			t.Fatal(err)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Errorf("expected 2 rows, got %d", n)
+	}
+}
+
+func TestPivotErrors(t *testing.T) {
+	cols := []string{"a", "b", "c", "d"}
+	rows := crosstabRows("x|p|1|1", "x|p|2|1", "y|q|3|abc")
+	tests := []struct {
+		cols []string
+		args []string
+		err  string
+	}{
+		{[]string{"a", "b"}, nil, "at least three columns"},
+		{cols, nil, "data column must be specified"},
+		{cols, []string{"a", "a", "c"}, "must be different columns"},
+		{cols, []string{"5", "b", "c"}, "out of range"},
+		{cols, []string{"z", "b", "c"}, "not found"},
+		{cols, []string{"a", "b", "c"}, `multiple data values for row "x", column "p"`},
+		{cols, []string{"b", "a", "c", "d"}, "must be an integer"},
+	}
+	for _, test := range tests {
+		_, err := pivot(test.cols, rows, test.args, "")
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%v %v: expected error %q, got %v", test.cols, test.args, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,564 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"gsmate/config"
+	"gsmate/pkg/client/metadata"
+
+	"github.com/pkg/errors"
+	"github.com/xo/tblfmt"
+)
+
+// relKinds maps the relation kind letters of the \d family to the table
+// types understood by DBClient.Tables.
+var relKinds = map[rune]string{
+	't': "BASE TABLE",
+	'v': "VIEW",
+	'm': "MATERIALIZED VIEW",
+	's': "SEQUENCE",
+	'i': "INDEX",
+	'E': "FOREIGN TABLE",
+}
+
+// defaultRelKinds are the relation kinds listed by a bare \d.
+const defaultRelKinds = "tvmsE"
+
+// funcKinds maps the function kind letters of \df to the function types
+// understood by DBClient.Functions.
+var funcKinds = map[rune]string{
+	'a': "AGGREGATE",
+	'n': "FUNCTION",
+	'p': "PROCEDURE",
+	't': "TRIGGER",
+	'w': "WINDOW",
+}
+
+// parsePattern converts a psql style object name pattern ("schema.name",
+// with * and ? wildcards and double quoted identifiers) into a filter with
+// LIKE patterns. Unquoted names are folded to lower case.
+func parsePattern(pattern string) metadata.Filter {
+	var parts []string
+	var sb strings.Builder
+	inQuotes := false
+	rs := []rune(pattern)
+	for i := 0; i < len(rs); i++ {
+		c := rs[i]
+		switch {
+		case c == '"' && inQuotes && i+1 < len(rs) && rs[i+1] == '"':
+			sb.WriteRune('"')
+			i++
+		case c == '"':
+			inQuotes = !inQuotes
+		case !inQuotes && c == '.':
+			parts = append(parts, sb.String())
+			sb.Reset()
+		case !inQuotes && c == '*':
+			sb.WriteRune('%')
+		case !inQuotes && c == '?':
+			sb.WriteRune('_')
+		case c == '%' || c == '_' || c == '\\':
+			sb.WriteRune('\\')
+			sb.WriteRune(c)
+		case !inQuotes:
+			sb.WriteRune(unicode.ToLower(c))
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	parts = append(parts, sb.String())
+
+	f := metadata.Filter{}
+	switch n := len(parts); {
+	case n == 1:
+		f.Name = parts[0]
+		f.OnlyVisible = true
+	default:
+		// a leading database part is accepted and ignored, like psql does
+		// for the current database
+		f.Schema, f.Name = parts[n-2], parts[n-1]
+	}
+	if f.Name == "%" {
+		f.Name = ""
+	}
+	return f
+}
+
+// likeEscape escapes the LIKE wildcards in an exact object name.
+func likeEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// encode renders rs with the current print settings, titled with title.
+func (c *DBClient) encode(w io.Writer, rs tblfmt.ResultSet, title string, footer bool) error {
+	params := make(map[string]string, len(config.GetPrintConfig())+2)
+	for k, v := range config.GetPrintConfig() {
+		params[k] = v
+	}
+	params["title"] = title
+	if !footer {
+		params["footer"] = "off"
+	}
+	return encodePaged(w, rs, params)
+}
+
+// ListTables lists the relations of the kinds given as \d letters
+// (eg "tv"), matching pattern.
+func (c *DBClient) ListTables(kinds, pattern string, verbose, showSystem bool) error {
+	if kinds == "" {
+		kinds = defaultRelKinds
+	}
+	f := parsePattern(pattern)
+	f.WithSystem = showSystem || pattern != ""
+	for _, k := range kinds {
+		if t, ok := relKinds[k]; ok {
+			f.Types = append(f.Types, t)
+		}
+	}
+	res, err := c.Tables(f)
+	if err != nil {
+		return err
+	}
+	if res.Len() == 0 {
+		if pattern != "" {
+			return errors.Errorf("Did not find any relation named \"%s\".", pattern)
+		}
+		fmt.Fprintln(c.Out(), "Did not find any relations.")
+		return nil
+	}
+
+	withParent := strings.ContainsRune(kinds, 'i')
+	cols := []string{"Schema", "Name", "Type", "Owner"}
+	if withParent {
+		cols = append(cols, "Table")
+	}
+	if verbose {
+		cols = append(cols, "Size", "Description")
+	}
+	res.SetColumns(cols)
+	res.SetScanValues(func(r metadata.Result) []interface{} {
+		t := r.(*metadata.Table)
+		v := []interface{}{t.Schema, t.Name, t.Type, t.Owner}
+		if withParent {
+			v = append(v, t.Parent)
+		}
+		if verbose {
+			v = append(v, t.Size, t.Comment.String)
+		}
+		return v
+	})
+	return c.encode(c.Out(), res, "List of relations", true)
+}
+
+// ListSchemas lists the schemas matching pattern.
+func (c *DBClient) ListSchemas(pattern string, verbose, showSystem bool) error {
+	f := parsePattern(pattern)
+	f.WithSystem = showSystem || pattern != ""
+	res, err := c.Schemas(f)
+	if err != nil {
+		return err
+	}
+	cols := []string{"Name", "Owner"}
+	if verbose {
+		cols = append(cols, "Access privileges", "Description")
+	}
+	res.SetColumns(cols)
+	res.SetScanValues(func(r metadata.Result) []interface{} {
+		s := r.(*metadata.Schema)
+		if verbose {
+			return []interface{}{s.Schema, s.Owner, s.AccessPrivileges, s.Comment}
+		}
+		return []interface{}{s.Schema, s.Owner}
+	})
+	return c.encode(c.Out(), res, "List of schemas", true)
+}
+
+// ListFunctions lists the functions matching pattern, kinds are the \df
+// function letters (eg "np"), empty for all kinds.
+func (c *DBClient) ListFunctions(kinds, pattern string, verbose, showSystem bool) error {
+	f := parsePattern(pattern)
+	f.WithSystem = showSystem || pattern != ""
+	for _, k := range kinds {
+		if t, ok := funcKinds[k]; ok {
+			f.Types = append(f.Types, t)
+		}
+	}
+	res, err := c.Functions(f)
+	if err != nil {
+		return err
+	}
+	cols := []string{"Schema", "Name", "Result data type", "Argument data types", "Type"}
+	if verbose {
+		cols = append(cols, "Volatility", "Security", "Language", "Source code")
+	}
+	res.SetColumns(cols)
+	res.SetScanValues(func(r metadata.Result) []interface{} {
+		fn := r.(*metadata.Function)
+		name := fn.Name
+		if fn.Package != "" {
+			name = fn.Package + "." + fn.Name
+		}
+		v := []interface{}{fn.Schema, name, fn.ResultType, fn.ArgTypes, fn.Type}
+		if verbose {
+			v = append(v, fn.Volatility, fn.Security, fn.Language, fn.Source)
+		}
+		return v
+	})
+	return c.encode(c.Out(), res, "List of functions", true)
+}
+
+// ListTypes lists the data types matching pattern.
+func (c *DBClient) ListTypes(pattern string, verbose, showSystem bool) error {
+	f := parsePattern(pattern)
+	f.WithSystem = showSystem || pattern != ""
+	res, err := c.Types(f)
+	if err != nil {
+		return err
+	}
+	cols := []string{"Schema", "Name", "Description"}
+	if verbose {
+		cols = []string{"Schema", "Name", "Internal name", "Size", "Elements", "Description"}
+	}
+	res.SetColumns(cols)
+	res.SetScanValues(func(r metadata.Result) []interface{} {
+		t := r.(*metadata.Type)
+		if verbose {
+			return t.Values()
+		}
+		return []interface{}{t.Schema, t.Name, t.Comment}
+	})
+	return c.encode(c.Out(), res, "List of data types", true)
+}
+
+// DescribeTableDetails describes every relation matching pattern.
+func (c *DBClient) DescribeTableDetails(pattern string, verbose, showSystem bool) error {
+	f := parsePattern(pattern)
+	f.WithSystem = true
+	res, err := c.Tables(f)
+	if err != nil {
+		return err
+	}
+	if res.Len() == 0 {
+		return errors.Errorf("Did not find any relation named \"%s\".", pattern)
+	}
+	var tables []metadata.Table
+	for res.Next() {
+		tables = append(tables, *res.Get())
+	}
+	for i := range tables {
+		if i != 0 {
+			fmt.Fprintln(c.Out())
+		}
+		if err := c.describeRelation(&tables[i], verbose); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// describeRelation prints the columns of t followed by its footers.
+func (c *DBClient) describeRelation(t *metadata.Table, verbose bool) error {
+	title := fmt.Sprintf(`%s "%s.%s"`, relTitle(t.Type), t.Schema, t.Name)
+	switch t.Type {
+	case "sequence", "large sequence":
+		return c.describeSequence(t, title)
+	case "index", "global index":
+		if err := c.describeIndex(t, title); err != nil {
+			return err
+		}
+	default:
+		if err := c.describeColumns(t, title, verbose); err != nil {
+			return err
+		}
+	}
+
+	footers, err := c.relationFooters(t)
+	if err != nil {
+		return err
+	}
+	for _, s := range footers {
+		fmt.Fprintln(c.Out(), s)
+	}
+	return nil
+}
+
+// describeColumns prints the columns of a table like relation.
+func (c *DBClient) describeColumns(t *metadata.Table, title string, verbose bool) error {
+	cols, err := c.Columns(metadata.Filter{
+		Schema:     likeEscape(t.Schema),
+		Parent:     likeEscape(t.Name),
+		WithSystem: true,
+	})
+	if err != nil {
+		return err
+	}
+	names := []string{"Column", "Type", "Modifiers"}
+	if verbose {
+		names = append(names, "Storage", "Description")
+	}
+	cols.SetColumns(names)
+	cols.SetScanValues(func(r metadata.Result) []interface{} {
+		col := r.(*metadata.Column)
+		var mods []string
+		if col.IsNullable == metadata.NO {
+			mods = append(mods, "not null")
+		}
+		if col.Default != "" {
+			mods = append(mods, "default "+col.Default)
+		}
+		v := []interface{}{col.Name, col.DataType, strings.Join(mods, " ")}
+		if verbose {
+			v = append(v, col.Storage, col.Comment)
+		}
+		return v
+	})
+	return c.encode(c.Out(), cols, title, false)
+}
+
+// describeSequence prints the parameters of a sequence.
+func (c *DBClient) describeSequence(t *metadata.Table, title string) error {
+	seqs, err := c.Sequences(metadata.Filter{
+		Schema:     likeEscape(t.Schema),
+		Name:       likeEscape(t.Name),
+		WithSystem: true,
+	})
+	if err != nil {
+		return err
+	}
+	return c.encode(c.Out(), seqs, title, false)
+}
+
+// describeIndex prints the key columns of an index.
+func (c *DBClient) describeIndex(t *metadata.Table, title string) error {
+	cols, err := c.IndexColumns(metadata.Filter{
+		Schema:     likeEscape(t.Schema),
+		Name:       likeEscape(t.Name),
+		WithSystem: true,
+	})
+	if err != nil {
+		return err
+	}
+	cols.SetColumns([]string{"Column", "Type"})
+	cols.SetScanValues(func(r metadata.Result) []interface{} {
+		col := r.(*metadata.IndexColumn)
+		return []interface{}{col.Name, col.DataType}
+	})
+	return c.encode(c.Out(), cols, title, false)
+}
+
+// relationFooters builds the footer lines printed below the columns of a
+// described relation.
+func (c *DBClient) relationFooters(t *metadata.Table) ([]string, error) {
+	var footers []string
+	switch t.Type {
+	case "index", "global index":
+		idx, err := c.Indexes(metadata.Filter{
+			Schema:     likeEscape(t.Schema),
+			Name:       likeEscape(t.Name),
+			WithSystem: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for idx.Next() {
+			footers = append(footers, indexFooter(idx.Get(), t.Parent))
+		}
+	case "table", "partitioned table", "materialized view":
+		idx, err := c.Indexes(metadata.Filter{
+			Schema:     likeEscape(t.Schema),
+			Parent:     likeEscape(t.Name),
+			WithSystem: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if idx.Len() != 0 {
+			footers = append(footers, "Indexes:")
+		}
+		for idx.Next() {
+			footers = append(footers, "    "+indexLine(idx.Get()))
+		}
+		if t.Type == "materialized view" {
+			break
+		}
+		cons, err := c.constraintFooters(t)
+		if err != nil {
+			return nil, err
+		}
+		footers = append(footers, cons...)
+	}
+	return footers, nil
+}
+
+// constraintKey identifies a constraint across the constraint readers.
+type constraintKey struct {
+	schema, table, name string
+}
+
+// constraintFooters builds the check constraint, foreign key and referenced
+// by footers of a table.
+func (c *DBClient) constraintFooters(t *metadata.Table) ([]string, error) {
+	own := metadata.Filter{
+		Schema:     likeEscape(t.Schema),
+		Parent:     likeEscape(t.Name),
+		WithSystem: true,
+		Types:      []string{"CHECK", "FOREIGN KEY"},
+	}
+	cons, err := c.Constraints(own)
+	if err != nil {
+		return nil, err
+	}
+	cols, err := c.constraintColumns(own)
+	if err != nil {
+		return nil, err
+	}
+	var checks, fks []string
+	for cons.Next() {
+		con := cons.Get()
+		switch con.Type {
+		case "CHECK":
+			checks = append(checks, fmt.Sprintf(`    "%s" %s`, con.Name, con.CheckClause))
+		case "FOREIGN KEY":
+			key := constraintKey{con.Schema, con.Table, con.Name}
+			fks = append(fks, fmt.Sprintf(`    "%s" %s`, con.Name, foreignKeyDef(con, cols[key])))
+		}
+	}
+
+	refFilter := metadata.Filter{
+		Reference:  likeEscape(t.Name),
+		WithSystem: true,
+		Types:      []string{"FOREIGN KEY"},
+	}
+	refs, err := c.Constraints(refFilter)
+	if err != nil {
+		return nil, err
+	}
+	refCols, err := c.constraintColumns(refFilter)
+	if err != nil {
+		return nil, err
+	}
+	var referenced []string
+	for refs.Next() {
+		con := refs.Get()
+		if con.ForeignSchema != t.Schema {
+			continue
+		}
+		key := constraintKey{con.Schema, con.Table, con.Name}
+		referenced = append(referenced, fmt.Sprintf(`    TABLE "%s.%s" CONSTRAINT "%s" %s`,
+			con.Schema, con.Table, con.Name, foreignKeyDef(con, refCols[key])))
+	}
+
+	var footers []string
+	for _, section := range []struct {
+		title string
+		lines []string
+	}{
+		{"Check constraints:", checks},
+		{"Foreign-key constraints:", fks},
+		{"Referenced by:", referenced},
+	} {
+		if len(section.lines) != 0 {
+			footers = append(footers, section.title)
+			footers = append(footers, section.lines...)
+		}
+	}
+	return footers, nil
+}
+
+// constraintColumns reads the constraint columns matching f, grouped by
+// constraint.
+func (c *DBClient) constraintColumns(f metadata.Filter) (map[constraintKey][]metadata.ConstraintColumn, error) {
+	f.Types = nil
+	res, err := c.ConstraintColumns(f)
+	if err != nil {
+		return nil, err
+	}
+	cols := map[constraintKey][]metadata.ConstraintColumn{}
+	for res.Next() {
+		col := res.Get()
+		key := constraintKey{col.Schema, col.Table, col.Constraint}
+		cols[key] = append(cols[key], *col)
+	}
+	return cols, nil
+}
+
+// foreignKeyDef formats a foreign key the way pg_get_constraintdef does.
+func foreignKeyDef(con *metadata.Constraint, cols []metadata.ConstraintColumn) string {
+	names := make([]string, 0, len(cols))
+	refs := make([]string, 0, len(cols))
+	for _, col := range cols {
+		names = append(names, col.Name)
+		refs = append(refs, col.ForeignName)
+	}
+	def := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s.%s(%s)",
+		strings.Join(names, ", "), con.ForeignSchema, con.ForeignTable, strings.Join(refs, ", "))
+	if con.MatchType == "FULL" || con.MatchType == "PARTIAL" {
+		def += " MATCH " + con.MatchType
+	}
+	if con.UpdateRule != "" && con.UpdateRule != "NO ACTION" {
+		def += " ON UPDATE " + con.UpdateRule
+	}
+	if con.DeleteRule != "" && con.DeleteRule != "NO ACTION" {
+		def += " ON DELETE " + con.DeleteRule
+	}
+	if con.IsDeferrable == metadata.YES {
+		def += " DEFERRABLE"
+		if con.IsInitiallyDeferred == metadata.YES {
+			def += " INITIALLY DEFERRED"
+		}
+	}
+	return def
+}
+
+// indexLine formats an index the way psql lists it under a table.
+func indexLine(i *metadata.Index) string {
+	var kind string
+	switch {
+	case i.IsPrimary == metadata.YES:
+		kind = "PRIMARY KEY, "
+	case i.IsUnique == metadata.YES:
+		kind = "UNIQUE, "
+	}
+	s := fmt.Sprintf(`"%s" %s%s (%s)`, i.Name, kind, i.Type, i.Columns)
+	if i.Scope != "" {
+		s += " " + i.Scope
+	}
+	return s
+}
+
+// indexFooter formats the footer of a described index.
+func indexFooter(i *metadata.Index, table string) string {
+	var kind string
+	switch {
+	case i.IsPrimary == metadata.YES:
+		kind = "primary key, "
+	case i.IsUnique == metadata.YES:
+		kind = "unique, "
+	}
+	return fmt.Sprintf(`%s%s, for table "%s.%s"`, kind, i.Type, i.Schema, table)
+}
+
+// relTitle capitalizes a relation type for use in a describe title.
+func relTitle(typ string) string {
+	if typ == "" {
+		return "Relation"
+	}
+	return strings.ToUpper(typ[:1]) + typ[1:]
+}
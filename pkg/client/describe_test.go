@@ -0,0 +1,55 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		schema  string
+		name    string
+		visible bool
+	}{
+		{"", "", "", true},
+		{"*", "", "", true},
+		{"foo", "", "foo", true},
+		{"Foo", "", "foo", true},
+		{"foo*", "", "foo%", true},
+		{"fo?", "", "fo_", true},
+		{"my_tab", "", `my\_tab`, true},
+		{"public.foo", "public", "foo", false},
+		{"public.*", "public", "", false},
+		{`"MyTable"`, "", "MyTable", true},
+		{`"My.Table"`, "", "My.Table", true},
+		{`"a""b"`, "", `a"b`, true},
+		{`"Public".t*`, "Public", "t%", false},
+		{"db.public.foo", "public", "foo", false},
+	}
+	for _, test := range tests {
+		f := parsePattern(test.pattern)
+		assert.Equal(t, test.schema, f.Schema, test.pattern)
+		assert.Equal(t, test.name, f.Name, test.pattern)
+		assert.Equal(t, test.visible, f.OnlyVisible, test.pattern)
+	}
+}
+
+func TestLikeEscape(t *testing.T) {
+	assert.Equal(t, `a\_b\%c\\d`, likeEscape(`a_b%c\d`))
+}
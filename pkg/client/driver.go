@@ -16,9 +16,25 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
 	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unsafe"
+
+	"gsmate/config"
+	"gsmate/internal/logger"
+	"gsmate/internal/utils"
+
+	pq "gitee.com/opengauss/openGauss-connector-go-pq"
+	"github.com/pkg/errors"
 )
 
+// errInvalidPassword is the SQLSTATE of a password authentication failure.
+const errInvalidPassword = "28P01"
+
 // DB is the common interface for database operations, compatible with
 // database/sql.DB and database/sql.Tx.
 type DB interface {
@@ -31,3 +47,200 @@ type DB interface {
 	Prepare(string) (*sql.Stmt, error)
 	PrepareContext(context.Context, string) (*sql.Stmt, error)
 }
+
+// node is the server a connection landed on.
+type node struct {
+	addr    config.Addr
+	standby bool
+}
+
+// role returns the replication role of the node.
+func (n node) role() string {
+	if n.standby {
+		return "standby"
+	}
+	return "primary"
+}
+
+// connect opens the connection of cfg, passing the notices of the server to
+// notice when not nil. When the server rejects the connection without
+// password, the password is prompted for unless prompting is disabled, and
+// kept in cfg for later connections.
+func connect(cfg *config.Config, notice func(*pq.Error)) (*sql.DB, node, error) {
+	db, n, err := open(cfg.Connection, notice)
+	if err == nil || cfg.Password != "" || cfg.NoPasswordPrompt || !isAuthError(err) {
+		return db, n, err
+	}
+	password, perr := utils.ReadPassword(fmt.Sprintf("Password for user %s: ", cfg.Username))
+	if perr != nil {
+		logger.Debug("read password: %s", perr)
+		return nil, n, err
+	}
+	cfg.Password = password
+	return open(cfg.Connection, notice)
+}
+
+// isAuthError reports whether err is a password authentication failure.
+func isAuthError(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == errInvalidPassword
+}
+
+// open connects to the first host of conn whose role satisfies the target
+// session attributes, trying them in order. With prefer-standby, the first
+// reachable host is used when none is a standby.
+func open(conn config.Connection, notice func(*pq.Error)) (*sql.DB, node, error) {
+	var firstErr error
+	var fallback *sql.DB
+	var fallbackNode node
+	for _, addr := range conn.Addrs() {
+		hc := conn
+		hc.Host, hc.Port = addr.Host, addr.Port
+		// resolved here rather than by the driver
+		hc.TargetSessionAttrs = ""
+		db, standby, err := openHost(hc, notice)
+		if err != nil {
+			logger.Debug("connect to %s: %s", addr, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		n := node{addr: addr, standby: standby}
+		if sessionMatches(conn.TargetSessionAttrs, standby) {
+			if fallback != nil {
+				_ = fallback.Close()
+			}
+			return db, n, nil
+		}
+		logger.Debug("skip %s server %s", n.role(), addr)
+		if conn.TargetSessionAttrs == "prefer-standby" && fallback == nil {
+			fallback, fallbackNode = db, n
+			continue
+		}
+		_ = db.Close()
+	}
+	if fallback != nil {
+		return fallback, fallbackNode, nil
+	}
+	if firstErr == nil {
+		firstErr = errors.Errorf("could not find a server with target_session_attrs=%s among %s",
+			conn.TargetSessionAttrs, conn.Host)
+	}
+	return nil, node{}, firstErr
+}
+
+// sessionMatches reports whether a server in recovery or not satisfies the
+// target session attributes.
+func sessionMatches(attrs string, standby bool) bool {
+	switch attrs {
+	case "read-write", "primary":
+		return !standby
+	case "read-only", "standby", "prefer-standby":
+		return standby
+	}
+	return true
+}
+
+// openHost connects to the single host of conn, reporting whether it is in
+// recovery. The allow and prefer ssl modes are resolved here, by trying the
+// connection without and with SSL in the order of the mode, so that they
+// behave like psql whatever the driver supports. Without a password, it is
+// looked up in the password file.
+func openHost(conn config.Connection, notice func(*pq.Error)) (*sql.DB, bool, error) {
+	if conn.Password == "" {
+		password, err := config.LookupPassword(config.PassFile(), &conn)
+		if err != nil {
+			logger.Warn("%s", err)
+		}
+		conn.Password = password
+	}
+
+	modes := []string{conn.SSLMode}
+	switch conn.SSLMode {
+	case "allow":
+		modes = []string{"disable", "require"}
+	case "prefer":
+		modes = []string{"require", "disable"}
+	}
+	var lastErr error
+	for _, mode := range modes {
+		conn.SSLMode = mode
+		connector, err := pq.NewConnector(conn.GetDSN())
+		if err != nil {
+			return nil, false, err
+		}
+		var db *sql.DB
+		if notice != nil {
+			db = sql.OpenDB(pq.ConnectorWithNoticeHandler(connector, notice))
+		} else {
+			db = sql.OpenDB(connector)
+		}
+		standby, err := checkHost(db, conn.ConnTimeout)
+		if err == nil {
+			return db, standby, nil
+		}
+		logger.Debug("connect with sslmode=%s: %s", mode, err)
+		_ = db.Close()
+		lastErr = attemptError(lastErr, err)
+	}
+	return nil, false, lastErr
+}
+
+// attemptError returns the error to report of the connection attempts with
+// different ssl modes that failed with prev and then err: an error of the
+// server, such as an authentication failure, rather than a failure to
+// negotiate SSL, and otherwise the last one.
+func attemptError(prev, err error) error {
+	var pqErr *pq.Error
+	if prev != nil && errors.As(prev, &pqErr) && !errors.As(err, &pqErr) {
+		return prev
+	}
+	return err
+}
+
+// checkHost pings db within timeout, when positive, and reports whether the
+// server is in recovery.
+func checkHost(db *sql.DB, timeout time.Duration) (bool, error) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	var standby bool
+	err := db.QueryRowContext(ctx, queryInRecovery).Scan(&standby)
+	return standby, err
+}
+
+// tlsState returns the TLS state of the driver connection driverConn when
+// it uses SSL. The driver keeps its network connection unexported, so it is
+// looked up among the fields of the connection.
+func tlsState(driverConn any) (tls.ConnectionState, bool) {
+	v := reflect.ValueOf(driverConn)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return tls.ConnectionState{}, false
+	}
+	v = v.Elem()
+	if !v.CanAddr() {
+		return tls.ConnectionState{}, false
+	}
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if f.Kind() != reflect.Interface || f.IsNil() {
+			continue
+		}
+		f = reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem()
+		if conn, ok := f.Interface().(*tls.Conn); ok {
+			return conn.ConnectionState(), true
+		}
+	}
+	return tls.ConnectionState{}, false
+}
+
+// sslInfo describes the protocol and cipher of a TLS connection the way
+// psql does, "protocol: TLSv1.3, cipher: TLS_AES_256_GCM_SHA384".
+func sslInfo(state tls.ConnectionState) string {
+	protocol := strings.Replace(tls.VersionName(state.Version), "TLS ", "TLSv", 1)
+	return fmt.Sprintf("protocol: %s, cipher: %s", protocol, tls.CipherSuiteName(state.CipherSuite))
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"database/sql"
+	"time"
+
+	"gsmate/internal/logger"
+
+	"github.com/pkg/errors"
+)
+
+// gset returns the func running a query as \gset, storing the columns of its
+// single row into the variables named after them, with varPrefix prepended.
+// A NULL column unsets its variable.
+func (c *DBClient) gset(varPrefix string) func(q, prefix string) error {
+	return func(q, prefix string) error {
+		start := time.Now()
+		rows, closeFunc, err := c.query(q)
+		if err != nil {
+			return err
+		}
+		defer closeFunc()
+		cols, err := rows.Columns()
+		if err != nil {
+			return err
+		}
+		vals, err := scanRow(rows, len(cols))
+		if err != nil {
+			return err
+		}
+		if vals == nil {
+			return errors.New("no rows returned for \\gset")
+		}
+		if rows.Next() {
+			return errors.New("more than one row returned for \\gset")
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for i, col := range cols {
+			c.gsetVar(varPrefix+col, vals[i])
+		}
+		c.recordTiming(queryTiming{total: time.Since(start)})
+		c.recordResult(prefix, 1, nil)
+		return nil
+	}
+}
+
+// gsetVar sets the variable name to v, unsetting it when v is NULL. The
+// special and read-only variables are left alone.
+func (c *DBClient) gsetVar(name string, v sql.NullString) {
+	_, special := specialVars[name]
+	if _, ok := c.connVars()[name]; ok || special {
+		logger.Warn("attempt to \\gset into specially treated variable %q ignored", name)
+		return
+	}
+	var err error
+	if v.Valid {
+		err = c.SetVar(name, v.String)
+	} else {
+		err = c.UnsetVar(name)
+	}
+	if err != nil {
+		logger.Warn("\\gset: %s", err)
+	}
+}
+
+// gexec runs the query q as \gexec: every non-NULL cell of its result, in row
+// then column order, is run as a statement of its own once the result is
+// read. A failed statement is reported and the next one run, unless
+// on_error_stop is set.
+func (c *DBClient) gexec(q, prefix string) error {
+	var stmts []string
+	err := c.run(q, prefix, func(q, prefix string) error {
+		start := time.Now()
+		rows, closeFunc, err := c.query(q)
+		if err != nil {
+			return err
+		}
+		defer closeFunc()
+		cols, err := rows.Columns()
+		if err != nil {
+			return err
+		}
+		var n int64
+		for {
+			vals, err := scanRow(rows, len(cols))
+			if err != nil {
+				return err
+			}
+			if vals == nil {
+				break
+			}
+			n++
+			for _, v := range vals {
+				if v.Valid {
+					stmts = append(stmts, v.String)
+				}
+			}
+		}
+		c.recordTiming(queryTiming{total: time.Since(start)})
+		c.recordResult(prefix, n, nil)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, stmt := range stmts {
+		if err := c.run(stmt, FindPrefix(stmt), c.doQuery); err != nil && c.cfg.OnErrorStop {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanRow scans the next row of n columns as strings, returning nil when
+// there are no rows left.
+func scanRow(rows *sql.Rows, n int) ([]sql.NullString, error) {
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	vals := make([]sql.NullString, n)
+	ptrs := make([]any, n)
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
+	if err := rows.Scan(ptrs...); err != nil {
+		return nil, err
+	}
+	return vals, nil
+}
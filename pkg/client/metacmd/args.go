@@ -0,0 +1,171 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metacmd
+
+import (
+	"strings"
+	"unicode"
+
+	"gsmate/internal/errdef"
+)
+
+// UnquoteFunc resolves a quoted string (isVar false) or a variable name
+// (isVar true), reporting whether it could be resolved.
+type UnquoteFunc func(s string, isVar bool) (bool, string, error)
+
+// Args reads the whitespace separated parameters of a meta command.
+//
+// Single quoted and backtick quoted parts are dequoted through the unquote
+// func, double quoted parts are kept verbatim so that identifiers and
+// patterns retain their quoting. Variables, as :name, :'name' or :"name",
+// are interpolated through the unquote func as well.
+type Args struct {
+	r       []rune
+	i       int
+	unquote UnquoteFunc
+}
+
+// NewArgs creates a parameter reader for the raw parameter string s.
+func NewArgs(s string, unquote UnquoteFunc) *Args {
+	return &Args{
+		r:       []rune(s),
+		unquote: unquote,
+	}
+}
+
+// Next reads the next parameter, returning false when there are no
+// parameters left.
+func (a *Args) Next() (string, bool, error) {
+	end := len(a.r)
+	for a.i < end && unicode.IsSpace(a.r[a.i]) {
+		a.i++
+	}
+	if a.i >= end {
+		return "", false, nil
+	}
+	var sb strings.Builder
+	for a.i < end && !unicode.IsSpace(a.r[a.i]) {
+		c := a.r[a.i]
+		if c == ':' && a.unquote != nil {
+			if name, j := readVar(a.r, a.i, end); j != a.i {
+				ok, z, err := a.unquote(name, true)
+				if err != nil {
+					return "", false, err
+				}
+				if ok {
+					sb.WriteString(z)
+					a.i = j
+					continue
+				}
+			}
+		}
+		if c != '\'' && c != '"' && c != '`' {
+			sb.WriteRune(c)
+			a.i++
+			continue
+		}
+		j, ok := readQuoted(a.r, a.i, end, c)
+		if !ok {
+			return "", false, errdef.ErrUnterminatedQuotedString
+		}
+		s := string(a.r[a.i : j+1])
+		a.i = j + 1
+		if c == '"' || a.unquote == nil {
+			sb.WriteString(s)
+			continue
+		}
+		_, z, err := a.unquote(s, false)
+		if err != nil {
+			return "", false, err
+		}
+		sb.WriteString(z)
+	}
+	return sb.String(), true, nil
+}
+
+// All reads all remaining parameters.
+func (a *Args) All() ([]string, error) {
+	var v []string
+	for {
+		s, ok, err := a.Next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return v, nil
+		}
+		v = append(v, s)
+	}
+}
+
+// Raw consumes and returns the remaining unprocessed parameter string with
+// surrounding whitespace removed.
+func (a *Args) Raw() string {
+	s := strings.TrimSpace(string(a.r[a.i:]))
+	a.i = len(a.r)
+	return s
+}
+
+// Target reads an output target: a file name, or the remaining parameter
+// string when it starts with "|", naming a shell command to pipe to.
+func (a *Args) Target() (string, error) {
+	if strings.HasPrefix(strings.TrimSpace(string(a.r[a.i:])), "|") {
+		return a.Raw(), nil
+	}
+	s, _, err := a.Next()
+	return s, err
+}
+
+// readQuoted finds the closing quote of the string starting at i, honoring
+// doubled quotes and backslash escapes in single quoted strings.
+func readQuoted(r []rune, i, end int, quote rune) (int, bool) {
+	for i++; i < end; i++ {
+		switch {
+		case quote == '\'' && r[i] == '\\':
+			i++
+		case r[i] == quote && i+1 < end && r[i+1] == quote:
+			i++
+		case r[i] == quote:
+			return i, true
+		}
+	}
+	return end, false
+}
+
+// readVar reads the variable reference starting with the colon at i,
+// returning the name as handed to the unquote func, quotes included, and the
+// end of the reference, or i when there is none.
+func readVar(r []rune, i, end int) (string, int) {
+	j := i + 1
+	if j < end && (r[j] == '\'' || r[j] == '"') {
+		quote := r[j]
+		for k := j + 1; k < end; k++ {
+			if r[k] == quote {
+				if k == j+1 {
+					return "", i
+				}
+				return string(r[j : k+1]), k + 1
+			}
+		}
+		return "", i
+	}
+	for j < end && (r[j] == '_' || unicode.IsLetter(r[j]) || unicode.IsDigit(r[j])) {
+		j++
+	}
+	if j == i+1 {
+		return "", i
+	}
+	return string(r[i+1 : j]), j
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metacmd
+
+import (
+	"strings"
+	"testing"
+
+	"gsmate/internal/errdef"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func trimQuotes(s string, _ bool) (bool, string, error) {
+	return true, strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+}
+
+func TestArgs(t *testing.T) {
+	tests := []struct {
+		s   string
+		exp []string
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"foo", []string{"foo"}},
+		{"  foo   bar ", []string{"foo", "bar"}},
+		{`'a b' c`, []string{"a b", "c"}},
+		{`'it''s'`, []string{"it's"}},
+		{`"My Table" x`, []string{`"My Table"`, "x"}},
+		{`public."My Table"`, []string{`public."My Table"`}},
+		{`'pg://'host'/'`, []string{"pg://host/"}},
+	}
+	for i, test := range tests {
+		v, err := NewArgs(test.s, trimQuotes).All()
+		assert.NoError(t, err, "test %d", i)
+		assert.Equal(t, test.exp, v, "test %d", i)
+	}
+}
+
+func TestArgsVars(t *testing.T) {
+	vars := map[string]string{"foo": "bar", "a b": "c"}
+	unquote := func(s string, isVar bool) (bool, string, error) {
+		if !isVar {
+			return trimQuotes(s, isVar)
+		}
+		q := ""
+		if s[0] == '\'' || s[0] == '"' {
+			q, s = s[:1], s[1:len(s)-1]
+		}
+		v, ok := vars[s]
+		return ok, q + v + q, nil
+	}
+	tests := []struct {
+		s   string
+		exp []string
+	}{
+		{":foo", []string{"bar"}},
+		{"x:foo: :'foo' :\"a b\"", []string{"xbar:", "'bar'", `"c"`}},
+		{":undefined ::foo", []string{":undefined", ":bar"}},
+		{`'pg://':foo'/'`, []string{"pg://bar/"}},
+	}
+	for i, test := range tests {
+		v, err := NewArgs(test.s, unquote).All()
+		assert.NoError(t, err, "test %d", i)
+		assert.Equal(t, test.exp, v, "test %d", i)
+	}
+}
+
+func TestArgsUnterminated(t *testing.T) {
+	_, err := NewArgs(`foo 'bar`, trimQuotes).All()
+	assert.ErrorIs(t, err, errdef.ErrUnterminatedQuotedString)
+}
+
+func TestArgsRaw(t *testing.T) {
+	a := NewArgs(" ls   -l  /tmp ", nil)
+	s, ok, err := a.Next()
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "ls", s)
+	assert.Equal(t, "-l  /tmp", a.Raw())
+	_, ok, _ = a.Next()
+	assert.False(t, ok)
+}
+
+func TestArgsTarget(t *testing.T) {
+	tests := []struct {
+		s, exp string
+	}{
+		{"", ""},
+		{" out.txt ", "out.txt"},
+		{"'my file.txt' rest", "my file.txt"},
+		{" | grep -v 'x y' ", "| grep -v 'x y'"},
+	}
+	for i, test := range tests {
+		v, err := NewArgs(test.s, trimQuotes).Target()
+		assert.NoError(t, err, "test %d", i)
+		assert.Equal(t, test.exp, v, "test %d", i)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	cmd, ok := Lookup(`\quit`)
+	assert.True(t, ok)
+	assert.Equal(t, "q", cmd.Name)
+	_, ok = Lookup(`\nonexistent`)
+	assert.False(t, ok)
+}
+
+func TestLookupModifiers(t *testing.T) {
+	for _, name := range []string{`\d`, `\dS`, `\d+`, `\dS+`, `\d+S`, `\dtS+`, `\dtv`, `\dmsS`} {
+		_, ok := Lookup(name)
+		assert.True(t, ok, name)
+	}
+	_, ok := Lookup(`\dx`)
+	assert.False(t, ok)
+
+	p := &Params{Name: "dtvS+"}
+	name, verbose, system := p.Modifiers("S+")
+	assert.Equal(t, "dtv", name)
+	assert.True(t, verbose)
+	assert.True(t, system)
+
+	p = &Params{Name: "dT"}
+	name, verbose, system = p.Modifiers("S+")
+	assert.Equal(t, "dT", name)
+	assert.False(t, verbose)
+	assert.False(t, system)
+}
@@ -0,0 +1,458 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metacmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"gsmate/internal/errdef"
+	"gsmate/internal/utils"
+	"gsmate/pkg/version"
+)
+
+func init() {
+	Register(&Cmd{
+		Section: SectionGeneral,
+		Name:    "copyright",
+		Desc:    "show gsmate usage and distribution terms",
+		Process: func(p *Params) error {
+			fmt.Fprintln(p.Handler.Out(), version.Copyright())
+			fmt.Fprintln(p.Handler.Out(), "Licensed under the Apache License, Version 2.0")
+			return nil
+		},
+	})
+	Register(&Cmd{
+		Section: SectionGeneral,
+		Name:    "g",
+		Usage:   "[FILE]",
+		Desc:    "execute query (and send results to file or |pipe)",
+		Process: func(p *Params) error {
+			return execTo(p, false)
+		},
+	})
+	Register(&Cmd{
+		Section: SectionGeneral,
+		Name:    "gx",
+		Usage:   "[FILE]",
+		Desc:    "as \\g, but forces expanded output mode",
+		Process: func(p *Params) error {
+			return execTo(p, true)
+		},
+	})
+	Register(&Cmd{
+		Section: SectionGeneral,
+		Name:    "gexec",
+		Desc:    "execute query, then execute each value in its result",
+		Process: func(p *Params) error {
+			p.Option.Exec = ExecExec
+			return nil
+		},
+	})
+	Register(&Cmd{
+		Section: SectionGeneral,
+		Name:    "gset",
+		Usage:   "[PREFIX]",
+		Desc:    "execute query and store result in internal variables",
+		Process: func(p *Params) error {
+			prefix, _, err := p.Args.Next()
+			if err != nil {
+				return err
+			}
+			p.Option.Exec = ExecSet
+			p.Option.Params = map[string]string{"prefix": prefix}
+			return nil
+		},
+	})
+	Register(&Cmd{
+		Section: SectionGeneral,
+		Name:    "q",
+		Desc:    "quit gsmate",
+		Aliases: []string{"quit"},
+		Process: func(p *Params) error {
+			p.Option.Quit = true
+			return nil
+		},
+	})
+	Register(&Cmd{
+		Section: SectionGeneral,
+		Name:    "!",
+		Usage:   "[COMMAND]",
+		Desc:    "execute command in shell or start interactive shell",
+		Process: func(p *Params) error {
+			return shell(p.Args.Raw())
+		},
+	})
+	Register(&Cmd{
+		Section: SectionGeneral,
+		Name:    "crosstabview",
+		Usage:   "[COLUMNS]",
+		Desc:    "execute query and display result in crosstab",
+		Process: func(p *Params) error {
+			cols, err := p.Args.All()
+			if err != nil {
+				return err
+			}
+			if len(cols) > 4 {
+				return errdef.ErrWrongNumberOfArguments
+			}
+			p.Option.Exec = ExecCrosstab
+			p.Option.Crosstab = cols
+			return nil
+		},
+	})
+	Register(&Cmd{
+		Section: SectionGeneral,
+		Name:    "errverbose",
+		Desc:    "show most recent error message at maximum verbosity",
+		Process: func(p *Params) error {
+			return p.Handler.ErrVerbose()
+		},
+	})
+	Register(&Cmd{
+		Section: SectionGeneral,
+		Name:    "timing",
+		Usage:   "[on|off]",
+		Desc:    "toggle timing of commands",
+		Process: func(p *Params) error {
+			v, _, err := p.Args.Next()
+			if err != nil {
+				return err
+			}
+			if v == "" {
+				v = "on"
+				if p.Handler.Vars()["TIMING"] == "on" {
+					v = "off"
+				}
+			}
+			if err := p.Handler.SetVar("TIMING", v); err != nil {
+				return err
+			}
+			fmt.Fprintf(p.Handler.Out(), "Timing is %s.\n", p.Handler.Vars()["TIMING"])
+			return nil
+		},
+	})
+	Register(&Cmd{
+		Section: SectionHelp,
+		Name:    "?",
+		Desc:    "show help on backslash commands",
+		Process: func(p *Params) error {
+			Help(p.Handler.Out())
+			return nil
+		},
+	})
+
+	Register(&Cmd{
+		Section: SectionQueryBuffer,
+		Name:    "w",
+		Aliases: []string{"write"},
+		Usage:   "FILE",
+		Desc:    "write query buffer to file",
+		Process: func(p *Params) error {
+			target, err := p.Args.Target()
+			if err != nil {
+				return err
+			}
+			if target == "" {
+				return errdef.ErrMissingRequiredArgument
+			}
+			return p.Handler.WriteBuffer(target)
+		},
+	})
+
+	Register(&Cmd{
+		Section: SectionInputOutput,
+		Name:    "o",
+		Aliases: []string{"out"},
+		Usage:   "[FILE]",
+		Desc:    "send all query results to file or |pipe",
+		Process: func(p *Params) error {
+			target, err := p.Args.Target()
+			if err != nil {
+				return err
+			}
+			return p.Handler.SetOutput(target)
+		},
+	})
+
+	Register(&Cmd{
+		Section:   SectionInformational,
+		Name:      "d",
+		Usage:     "[NAME]",
+		Desc:      "list tables, views, and sequences, or describe NAME",
+		Modifiers: "S+",
+		Process:   listRelations,
+	})
+	for _, cmd := range []struct {
+		name, desc string
+		match      *regexp.Regexp
+	}{
+		{"dE", "list foreign tables", nil},
+		{"di", "list indexes", nil},
+		{"dm", "list materialized views", nil},
+		{"ds", "list sequences", nil},
+		// also accepts combined relation kinds, eg \dtv
+		{"dt", "list tables", regexp.MustCompile(`^d[Eimstv]{2,}[S+]*$`)},
+		{"dv", "list views", nil},
+	} {
+		Register(&Cmd{
+			Section:   SectionInformational,
+			Name:      cmd.name,
+			Usage:     "[PATTERN]",
+			Desc:      cmd.desc,
+			Modifiers: "S+",
+			Match:     cmd.match,
+			Process:   listRelations,
+		})
+	}
+	Register(&Cmd{
+		Section:   SectionInformational,
+		Name:      "df",
+		Usage:     "[PATTERN]",
+		Desc:      "list functions, \\df[anptw] for only aggregate/normal/procedure/trigger/window",
+		Modifiers: "S+",
+		Match:     regexp.MustCompile(`^df[anptw]+[S+]*$`),
+		Process: func(p *Params) error {
+			name, _, _ := p.Modifiers("S+")
+			pattern, verbose, system, err := describeArgs(p)
+			if err != nil {
+				return err
+			}
+			return p.Handler.ListFunctions(strings.TrimPrefix(name, "df"), pattern, verbose, system)
+		},
+	})
+	Register(&Cmd{
+		Section:   SectionInformational,
+		Name:      "dn",
+		Usage:     "[PATTERN]",
+		Desc:      "list schemas",
+		Modifiers: "S+",
+		Process: func(p *Params) error {
+			pattern, verbose, system, err := describeArgs(p)
+			if err != nil {
+				return err
+			}
+			return p.Handler.ListSchemas(pattern, verbose, system)
+		},
+	})
+	Register(&Cmd{
+		Section:   SectionInformational,
+		Name:      "dT",
+		Usage:     "[PATTERN]",
+		Desc:      "list data types",
+		Modifiers: "S+",
+		Process: func(p *Params) error {
+			pattern, verbose, system, err := describeArgs(p)
+			if err != nil {
+				return err
+			}
+			return p.Handler.ListTypes(pattern, verbose, system)
+		},
+	})
+
+	Register(&Cmd{
+		Section: SectionConnection,
+		Name:    "c",
+		Aliases: []string{"connect"},
+		Usage:   "[PROFILE|CONNINFO|DBNAME [USER] [HOST] [PORT]]",
+		Desc:    "connect to a config profile or new database",
+		Process: func(p *Params) error {
+			params, err := p.Args.All()
+			if err != nil {
+				return err
+			}
+			return p.Handler.Connect(params)
+		},
+	})
+	Register(&Cmd{
+		Section: SectionConnection,
+		Name:    "password",
+		Usage:   "[USERNAME]",
+		Desc:    "securely change the password for a user",
+		Process: func(p *Params) error {
+			user, _, err := p.Args.Next()
+			if err != nil {
+				return err
+			}
+			return p.Handler.ChangePassword(user)
+		},
+	})
+
+	Register(&Cmd{
+		Section: SectionVariables,
+		Name:    "set",
+		Usage:   "[NAME [VALUE]]",
+		Desc:    "set internal variable, or list all if no parameters",
+		Process: func(p *Params) error {
+			params, err := p.Args.All()
+			if err != nil {
+				return err
+			}
+			if len(params) == 0 {
+				listVars(p.Handler.Out(), p.Handler.Vars())
+				return nil
+			}
+			return p.Handler.SetVar(params[0], strings.Join(params[1:], ""))
+		},
+	})
+	Register(&Cmd{
+		Section: SectionVariables,
+		Name:    "unset",
+		Usage:   "NAME",
+		Desc:    "unset (delete) internal variable",
+		Process: func(p *Params) error {
+			name, ok, err := p.Args.Next()
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return errdef.ErrMissingRequiredArgument
+			}
+			return p.Handler.UnsetVar(name)
+		},
+	})
+	Register(&Cmd{
+		Section: SectionVariables,
+		Name:    "echo",
+		Usage:   "[-n] [STRING]",
+		Desc:    "write string to standard output (-n for no newline)",
+		Process: func(p *Params) error {
+			params, err := p.Args.All()
+			if err != nil {
+				return err
+			}
+			end := "\n"
+			if len(params) != 0 && params[0] == "-n" {
+				params, end = params[1:], ""
+			}
+			fmt.Fprint(p.Handler.Out(), strings.Join(params, " ")+end)
+			return nil
+		},
+	})
+}
+
+// execTo requests the execution of the query buffer, sending the results to
+// the optional target parameter, in expanded mode when expanded is set.
+func execTo(p *Params, expanded bool) error {
+	target, err := p.Args.Target()
+	if err != nil {
+		return err
+	}
+	p.Option.Exec = ExecOnly
+	if target != "" || expanded {
+		p.Option.Params = map[string]string{}
+	}
+	if target != "" {
+		p.Option.Exec = ExecPipe
+		p.Option.Params["pipe"] = target
+	}
+	if expanded {
+		p.Option.Params["expanded"] = "on"
+	}
+	return nil
+}
+
+// listVars writes the variables sorted by name.
+func listVars(w io.Writer, vars map[string]string) {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s = '%s'\n", name, vars[name])
+	}
+}
+
+// describeArgs reads the modifiers and the optional pattern of a describe
+// command.
+func describeArgs(p *Params) (string, bool, bool, error) {
+	_, verbose, system := p.Modifiers("S+")
+	pattern, _, err := p.Args.Next()
+	return pattern, verbose, system, err
+}
+
+// listRelations backs \d and the \d<kinds> commands.
+func listRelations(p *Params) error {
+	name, _, _ := p.Modifiers("S+")
+	pattern, verbose, system, err := describeArgs(p)
+	if err != nil {
+		return err
+	}
+	kinds := strings.TrimPrefix(name, "d")
+	if kinds == "" && pattern != "" {
+		return p.Handler.DescribeTableDetails(pattern, verbose, system)
+	}
+	return p.Handler.ListTables(kinds, pattern, verbose, system)
+}
+
+// Help writes the help of all registered commands, grouped by section.
+func Help(w io.Writer) {
+	for i, section := range sections {
+		if i != 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, section)
+		if note, ok := sectionNotes[section]; ok {
+			fmt.Fprintln(w, "  "+note)
+		}
+		for _, cmd := range cmdList {
+			if cmd.Section != section {
+				continue
+			}
+			name := `\` + cmd.Name
+			if cmd.Modifiers != "" {
+				name += "[" + cmd.Modifiers + "]"
+			}
+			if cmd.Usage != "" {
+				name += " " + cmd.Usage
+			}
+			fmt.Fprintf(w, "  %-24s %s\n", name, cmd.Desc)
+		}
+	}
+}
+
+// shell runs command in the user's shell, or starts an interactive shell
+// when command is empty.
+func shell(command string) error {
+	cmd := ShellCommand(command)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// ShellCommand returns the command running command in the user's shell, or
+// an interactive shell when command is empty.
+func ShellCommand(command string) *exec.Cmd {
+	sh, ok := utils.Getenv("SHELL")
+	if !ok {
+		sh = "/bin/sh"
+		if runtime.GOOS == "windows" {
+			sh = "cmd"
+		}
+	}
+	switch {
+	case command == "":
+		return exec.Command(sh)
+	case strings.HasSuffix(sh, "cmd") || strings.HasSuffix(sh, "cmd.exe"):
+		return exec.Command(sh, "/c", command)
+	}
+	return exec.Command(sh, "-c", command)
+}
@@ -0,0 +1,225 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metacmd
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	"gsmate/internal/errdef"
+
+	"github.com/pkg/errors"
+)
+
+// Handler is the interface the client implements to back meta commands.
+type Handler interface {
+	// Out returns the writer meta command output should be written to.
+	Out() io.Writer
+	// Connect opens a new connection, either to the config profile or
+	// conninfo string given as single parameter, or to
+	// "DBNAME [USER] [HOST] [PORT]" where "-" or a missing parameter keeps
+	// the current value.
+	Connect(params []string) error
+	// ChangePassword prompts for and sets the password of user, the current
+	// user when empty.
+	ChangePassword(user string) error
+	// TxStatus returns the transaction status of the session.
+	TxStatus() TxStatus
+	// ErrVerbose prints the last error of the server with all its fields.
+	ErrVerbose() error
+	// SetVar sets the variable name to value, validating the value of the
+	// special variables such as AUTOCOMMIT.
+	SetVar(name, value string) error
+	// UnsetVar deletes the variable name.
+	UnsetVar(name string) error
+	// Vars returns the values of the variables by name.
+	Vars() map[string]string
+	// SetOutput sends the query results to target, a file or a "|command"
+	// pipe, or back to the standard output when empty.
+	SetOutput(target string) error
+	// WriteBuffer writes the query buffer, or the previous query when empty,
+	// to target, a file or a "|command" pipe.
+	WriteBuffer(target string) error
+
+	Describer
+}
+
+// Describer is the interface backing the \d family of commands.
+type Describer interface {
+	// DescribeTableDetails describes the relations matching pattern.
+	DescribeTableDetails(pattern string, verbose, showSystem bool) error
+	// ListTables lists the relations matching pattern, kinds are the \d
+	// relation letters (eg "tv"), empty for the default set.
+	ListTables(kinds, pattern string, verbose, showSystem bool) error
+	// ListSchemas lists the schemas matching pattern.
+	ListSchemas(pattern string, verbose, showSystem bool) error
+	// ListFunctions lists the functions matching pattern, kinds are the \df
+	// function letters (eg "np"), empty for all kinds.
+	ListFunctions(kinds, pattern string, verbose, showSystem bool) error
+	// ListTypes lists the data types matching pattern.
+	ListTypes(pattern string, verbose, showSystem bool) error
+}
+
+// Section is a help section of meta commands.
+type Section string
+
+const (
+	SectionGeneral       Section = "General"
+	SectionHelp          Section = "Help"
+	SectionQueryBuffer   Section = "Query Buffer"
+	SectionInputOutput   Section = "Input/Output"
+	SectionInformational Section = "Informational"
+	SectionFormatting    Section = "Formatting"
+	SectionConnection    Section = "Connection"
+	SectionVariables     Section = "Variables"
+)
+
+// sections is the display order of the help sections.
+var sections = []Section{
+	SectionGeneral,
+	SectionHelp,
+	SectionQueryBuffer,
+	SectionInputOutput,
+	SectionInformational,
+	SectionFormatting,
+	SectionConnection,
+	SectionVariables,
+}
+
+// sectionNotes are printed below the title of a help section.
+var sectionNotes = map[Section]string{
+	SectionInformational: "(options: S = show system objects, + = additional detail)",
+}
+
+// Cmd is a meta command definition.
+type Cmd struct {
+	// Section is the help section the command is listed in.
+	Section Section
+	// Name is the primary name of the command, without the leading backslash.
+	Name string
+	// Usage is the argument synopsis shown in help, eg "[COMMAND]".
+	Usage string
+	// Desc is the one line description shown in help.
+	Desc string
+	// Aliases are alternative names of the command.
+	Aliases []string
+	// Modifiers are the suffix characters the command accepts in any
+	// combination, eg "S+" for \dt, \dtS, \dt+ and \dtS+.
+	Modifiers string
+	// Match optionally matches names not registered verbatim.
+	Match *regexp.Regexp
+	// Process executes the command.
+	Process func(*Params) error
+}
+
+// Params holds the runtime state of a meta command execution.
+type Params struct {
+	// Handler is the client backing the command.
+	Handler Handler
+	// Name is the command name as typed by the user.
+	Name string
+	// Args are the raw command parameters.
+	Args *Args
+	// Option is the execution result handed back to the client.
+	Option Option
+}
+
+var (
+	// cmdList is the registered commands in registration order.
+	cmdList []*Cmd
+	// cmdMap maps command names and aliases to the command.
+	cmdMap = map[string]*Cmd{}
+)
+
+// Register adds cmd to the meta command registry.
+func Register(cmd *Cmd) {
+	cmdList = append(cmdList, cmd)
+	suffixes := modifierSuffixes(cmd.Modifiers)
+	for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+		for _, suffix := range suffixes {
+			cmdMap[name+suffix] = cmd
+		}
+	}
+}
+
+// modifierSuffixes returns every ordering of every subset of modifiers,
+// including the empty one.
+func modifierSuffixes(modifiers string) []string {
+	suffixes := []string{""}
+	for i, m := range modifiers {
+		rest := modifiers[:i] + modifiers[i+1:]
+		for _, s := range modifierSuffixes(rest) {
+			suffixes = append(suffixes, string(m)+s)
+		}
+	}
+	return suffixes
+}
+
+// Cmds returns all registered commands in registration order.
+func Cmds() []*Cmd {
+	return cmdList
+}
+
+// Lookup finds the command registered with name, which may have a leading
+// backslash.
+func Lookup(name string) (*Cmd, bool) {
+	name = strings.TrimPrefix(name, `\`)
+	if cmd, ok := cmdMap[name]; ok {
+		return cmd, true
+	}
+	for _, cmd := range cmdList {
+		if cmd.Match != nil && cmd.Match.MatchString(name) {
+			return cmd, true
+		}
+	}
+	return nil, false
+}
+
+// Modifiers strips the accepted modifier characters from the end of the
+// invoked name, returning the base name and whether the verbose (+) and
+// system (S) modifiers were given.
+func (p *Params) Modifiers(accepted string) (string, bool, bool) {
+	name := p.Name
+	var verbose, system bool
+	for len(name) > 0 && strings.ContainsRune(accepted, rune(name[len(name)-1])) {
+		switch name[len(name)-1] {
+		case '+':
+			verbose = true
+		case 'S':
+			system = true
+		}
+		name = name[:len(name)-1]
+	}
+	return name, verbose, system
+}
+
+// Run decodes and executes the meta command name with the raw parameter
+// string args, returning the resulting execution option.
+func Run(h Handler, name string, args *Args) (Option, error) {
+	cmd, ok := Lookup(name)
+	if !ok {
+		return Option{}, errors.Wrapf(errdef.ErrUnknownCommand, `\%s`, strings.TrimPrefix(name, `\`))
+	}
+	p := &Params{
+		Handler: h,
+		Name:    strings.TrimPrefix(name, `\`),
+		Args:    args,
+	}
+	if err := cmd.Process(p); err != nil {
+		return p.Option, errors.Wrapf(err, `\%s`, p.Name)
+	}
+	return p.Option, nil
+}
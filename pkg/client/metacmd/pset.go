@@ -0,0 +1,232 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metacmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"gsmate/config"
+)
+
+func init() {
+	Register(&Cmd{
+		Section: SectionFormatting,
+		Name:    "a",
+		Desc:    "toggle between unaligned and aligned output mode",
+		Process: func(p *Params) error {
+			format := "unaligned"
+			if config.GetPrintConfig()["format"] == "unaligned" {
+				format = "aligned"
+			}
+			return pset(p.Handler.Out(), "format", format)
+		},
+	})
+	Register(&Cmd{
+		Section: SectionFormatting,
+		Name:    "C",
+		Usage:   "[STRING]",
+		Desc:    "set table title, or unset if none",
+		Process: func(p *Params) error {
+			params, err := p.Args.All()
+			if err != nil {
+				return err
+			}
+			return pset(p.Handler.Out(), "title", strings.Join(params, " "))
+		},
+	})
+	Register(&Cmd{
+		Section: SectionFormatting,
+		Name:    "f",
+		Usage:   "[STRING]",
+		Desc:    "show or set field separator for unaligned query output",
+		Process: func(p *Params) error {
+			return psetArg(p, "fieldsep")
+		},
+	})
+	Register(&Cmd{
+		Section: SectionFormatting,
+		Name:    "H",
+		Desc:    "toggle HTML output mode",
+		Process: func(p *Params) error {
+			format := "html"
+			if config.GetPrintConfig()["format"] == "html" {
+				format = "aligned"
+			}
+			return pset(p.Handler.Out(), "format", format)
+		},
+	})
+	Register(&Cmd{
+		Section: SectionFormatting,
+		Name:    "pset",
+		Usage:   "[NAME [VALUE]]",
+		Desc:    "set table output option, or list all if no parameters",
+		Process: func(p *Params) error {
+			name, ok, err := p.Args.Next()
+			if err != nil {
+				return err
+			}
+			if !ok {
+				listPrintOptions(p.Handler.Out(), config.GetPrintConfig())
+				return nil
+			}
+			return psetArg(p, name)
+		},
+	})
+	Register(&Cmd{
+		Section: SectionFormatting,
+		Name:    "t",
+		Usage:   "[on|off]",
+		Desc:    "show only rows",
+		Process: func(p *Params) error {
+			return psetArg(p, "tuples_only")
+		},
+	})
+	Register(&Cmd{
+		Section: SectionFormatting,
+		Name:    "x",
+		Usage:   "[on|off|auto]",
+		Desc:    "toggle expanded output",
+		Process: func(p *Params) error {
+			return psetArg(p, "expanded")
+		},
+	})
+}
+
+// psetArg sets the print option name to the next parameter, or without
+// one toggles it when boolean, unsets the title and table attributes, and
+// shows the other options.
+func psetArg(p *Params, name string) error {
+	value, ok, err := p.Args.Next()
+	if err != nil {
+		return err
+	}
+	if ok {
+		return pset(p.Handler.Out(), name, value)
+	}
+	cur, known := config.GetPrintConfig()[name]
+	switch {
+	case !known:
+		// reports the unrecognized option
+		return config.SetPrintOption(name, "")
+	case config.IsBoolPrintOption(name), name == "expanded", name == "pager":
+		// auto and always toggle off
+		value = "off"
+		if cur == "off" {
+			value = "on"
+		}
+	case name == "title", name == "tableattr":
+		value = ""
+	default:
+		fmt.Fprintln(p.Handler.Out(), printOptionInfo(name, config.GetPrintConfig()))
+		return nil
+	}
+	return pset(p.Handler.Out(), name, value)
+}
+
+// pset sets the print option name to value and reports its new state.
+func pset(w io.Writer, name, value string) error {
+	if err := config.SetPrintOption(name, value); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, printOptionInfo(name, config.GetPrintConfig()))
+	return nil
+}
+
+// listPrintOptions writes all the print options, sorted by name.
+func listPrintOptions(w io.Writer, opts map[string]string) {
+	for _, name := range config.PrintOptions() {
+		v := opts[name]
+		if config.PrintOptionValues(name) == nil && !config.IsIntPrintOption(name) {
+			v = "'" + strings.ReplaceAll(v, "\n", `\n`) + "'"
+		}
+		fmt.Fprintf(w, "%-24s %s\n", name, v)
+	}
+}
+
+// printOptionInfo describes the state of the print option name the way
+// psql does.
+func printOptionInfo(name string, opts map[string]string) string {
+	v := opts[name]
+	switch name {
+	case "border":
+		return fmt.Sprintf("Border style is %s.", v)
+	case "columns":
+		if v == "0" {
+			return "Target width is unset."
+		}
+		return fmt.Sprintf("Target width is %s.", v)
+	case "csv_fieldsep":
+		return fmt.Sprintf("Field separator for CSV is %q.", v)
+	case "expanded":
+		if v == "auto" {
+			return "Expanded display is used automatically."
+		}
+		return fmt.Sprintf("Expanded display is %s.", v)
+	case "fieldsep", "fieldsep_zero":
+		if opts["fieldsep_zero"] == "on" {
+			return "Field separator is zero byte."
+		}
+		return fmt.Sprintf("Field separator is %q.", opts["fieldsep"])
+	case "footer":
+		return fmt.Sprintf("Default footer is %s.", v)
+	case "format":
+		return fmt.Sprintf("Output format is %s.", v)
+	case "linestyle":
+		return fmt.Sprintf("Line style is %s.", v)
+	case "null":
+		return fmt.Sprintf("Null display is %q.", v)
+	case "numericlocale":
+		return fmt.Sprintf("Locale-adjusted numeric output is %s.", v)
+	case "pager":
+		switch v {
+		case "on":
+			return "Pager is used for long output."
+		case "always":
+			return "Pager is always used."
+		}
+		return "Pager usage is off."
+	case "pager_min_lines":
+		if v == "1" {
+			return "Pager won't be used for less than 1 line."
+		}
+		return fmt.Sprintf("Pager won't be used for less than %s lines.", v)
+	case "recordsep", "recordsep_zero":
+		if opts["recordsep_zero"] == "on" {
+			return "Record separator is zero byte."
+		}
+		if opts["recordsep"] == "\n" {
+			return "Record separator is <newline>."
+		}
+		return fmt.Sprintf("Record separator is %q.", opts["recordsep"])
+	case "tableattr":
+		if v == "" {
+			return "Table attributes unset."
+		}
+		return fmt.Sprintf("Table attributes are %q.", v)
+	case "title":
+		if v == "" {
+			return "Title is unset."
+		}
+		return fmt.Sprintf("Title is %q.", v)
+	case "tuples_only":
+		return fmt.Sprintf("Tuples only is %s.", v)
+	case "unicode_border_linestyle", "unicode_column_linestyle", "unicode_header_linestyle":
+		kind := strings.TrimSuffix(strings.TrimPrefix(name, "unicode_"), "_linestyle")
+		return fmt.Sprintf("Unicode %s line style is %q.", kind, v)
+	}
+	return fmt.Sprintf("%s is %q.", name, v)
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metacmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintOptionInfo(t *testing.T) {
+	opts := map[string]string{
+		"border":                   "2",
+		"columns":                  "0",
+		"expanded":                 "auto",
+		"fieldsep":                 "|",
+		"fieldsep_zero":            "off",
+		"null":                     "(null)",
+		"pager":                    "always",
+		"recordsep":                "\n",
+		"recordsep_zero":           "off",
+		"title":                    "",
+		"tuples_only":              "on",
+		"unicode_header_linestyle": "double",
+	}
+	tests := []struct {
+		name, exp string
+	}{
+		{"border", "Border style is 2."},
+		{"columns", "Target width is unset."},
+		{"expanded", "Expanded display is used automatically."},
+		{"fieldsep", `Field separator is "|".`},
+		{"null", `Null display is "(null)".`},
+		{"pager", "Pager is always used."},
+		{"recordsep", "Record separator is <newline>."},
+		{"title", "Title is unset."},
+		{"tuples_only", "Tuples only is on."},
+		{"unicode_header_linestyle", `Unicode header line style is "double".`},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.exp, printOptionInfo(test.name, opts), test.name)
+	}
+}
@@ -52,3 +52,27 @@ type Option struct {
 	// Watch is the watch duration interval.
 	Watch time.Duration
 }
+
+// TxStatus is the transaction status of the session.
+type TxStatus int
+
+const (
+	// TxIdle indicates no open transaction block.
+	TxIdle TxStatus = iota
+	// TxActive indicates an open transaction block.
+	TxActive
+	// TxFailed indicates a transaction block aborted by an error, awaiting
+	// ROLLBACK.
+	TxFailed
+)
+
+// String satisfies the fmt.Stringer interface.
+func (s TxStatus) String() string {
+	switch s {
+	case TxActive:
+		return "in transaction"
+	case TxFailed:
+		return "in failed transaction"
+	}
+	return "idle"
+}
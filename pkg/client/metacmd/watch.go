@@ -0,0 +1,91 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metacmd
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// defaultWatchInterval is the interval of \watch when not given.
+const defaultWatchInterval = 2 * time.Second
+
+func init() {
+	Register(&Cmd{
+		Section: SectionGeneral,
+		Name:    "watch",
+		Usage:   "[[i=]SEC] [[c=]N] [h]",
+		Desc:    "execute query every SEC seconds, up to N times, h highlights changes",
+		Process: func(p *Params) error {
+			params, err := p.Args.All()
+			if err != nil {
+				return err
+			}
+			interval, count, highlight, err := parseWatch(params)
+			if err != nil {
+				return err
+			}
+			p.Option.Exec = ExecWatch
+			p.Option.Watch = interval
+			p.Option.Params = map[string]string{"count": strconv.Itoa(count)}
+			if highlight {
+				p.Option.Params["highlight"] = "on"
+			}
+			return nil
+		},
+	})
+}
+
+// parseWatch parses the \watch parameters: the interval in seconds and the
+// number of runs, either positional or named i= and c=, and the h[ighlight]
+// flag. A count of 0 runs until interrupted.
+func parseWatch(params []string) (time.Duration, int, bool, error) {
+	interval, count, highlight := defaultWatchInterval, 0, false
+	var hasInterval, hasCount bool
+	for _, param := range params {
+		name, v, named := strings.Cut(param, "=")
+		if !named {
+			name, v = "", param
+		}
+		switch {
+		case !named && (v == "h" || v == "highlight"):
+			highlight = true
+		case name == "i" || name == "interval" || (!named && !hasInterval):
+			if hasInterval {
+				return 0, 0, false, errors.New("interval value is specified more than once")
+			}
+			sec, err := strconv.ParseFloat(v, 64)
+			if err != nil || sec < 0 {
+				return 0, 0, false, errors.Errorf("incorrect interval value %q", v)
+			}
+			interval, hasInterval = time.Duration(sec*float64(time.Second)), true
+		case name == "c" || name == "count" || (!named && !hasCount):
+			if hasCount {
+				return 0, 0, false, errors.New("iteration count is specified more than once")
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				return 0, 0, false, errors.Errorf("incorrect iteration count %q", v)
+			}
+			count, hasCount = n, true
+		default:
+			return 0, 0, false, errors.Errorf("unrecognized parameter %q", param)
+		}
+	}
+	return interval, count, highlight, nil
+}
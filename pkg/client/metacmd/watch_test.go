@@ -0,0 +1,56 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metacmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWatch(t *testing.T) {
+	tests := []struct {
+		params    []string
+		interval  time.Duration
+		count     int
+		highlight bool
+		err       bool
+	}{
+		{nil, 2 * time.Second, 0, false, false},
+		{[]string{"5"}, 5 * time.Second, 0, false, false},
+		{[]string{"0.5", "10"}, 500 * time.Millisecond, 10, false, false},
+		{[]string{"c=3", "i=1"}, time.Second, 3, false, false},
+		{[]string{"1", "h"}, time.Second, 0, true, false},
+		{[]string{"highlight", "c=2"}, 2 * time.Second, 2, true, false},
+		{[]string{"-1"}, 0, 0, false, true},
+		{[]string{"abc"}, 0, 0, false, true},
+		{[]string{"1", "0"}, 0, 0, false, true},
+		{[]string{"i=1", "i=2"}, 0, 0, false, true},
+		{[]string{"1", "2", "3"}, 0, 0, false, true},
+		{[]string{"x=1"}, 0, 0, false, true},
+	}
+	for _, test := range tests {
+		interval, count, highlight, err := parseWatch(test.params)
+		if test.err {
+			assert.Error(t, err, "%v", test.params)
+			continue
+		}
+		assert.NoError(t, err, "%v", test.params)
+		assert.Equal(t, test.interval, interval, "%v", test.params)
+		assert.Equal(t, test.count, count, "%v", test.params)
+		assert.Equal(t, test.highlight, highlight, "%v", test.params)
+	}
+}
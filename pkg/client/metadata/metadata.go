@@ -176,8 +176,11 @@ func (s SchemaSet) Get() *Schema {
 }
 
 type Schema struct {
-	Schema  string
-	Catalog string
+	Schema           string
+	Catalog          string
+	Owner            string
+	AccessPrivileges string
+	Comment          string
 }
 
 func (s Schema) Values() []interface{} {
@@ -221,6 +224,9 @@ type Table struct {
 	Rows    int64
 	Size    string
 	Comment sql.NullString
+	Owner   string
+	// Parent is the table an index belongs to, empty for other relations.
+	Parent string
 }
 
 func (t Table) Values() []interface{} {
@@ -283,6 +289,8 @@ type Column struct {
 	NumPrecRadix    int
 	CharOctetLength int
 	IsNullable      Bool
+	Storage         string
+	Comment         string
 }
 
 type Bool string
@@ -416,6 +424,8 @@ type Index struct {
 	IsUnique  Bool
 	Type      string
 	Columns   string
+	// Scope is LOCAL or GLOBAL for indexes of partitioned tables.
+	Scope string
 }
 
 func (i Index) Values() []interface{} {
@@ -667,6 +677,8 @@ type Function struct {
 	Security   string
 	Language   string
 	Source     string
+	// Package is the package the function or procedure is declared in.
+	Package string
 
 	SpecificName string
 }
@@ -802,6 +814,55 @@ func (s Sequence) Values() []interface{} {
 	}
 }
 
+type TypeSet struct {
+	resultSet
+}
+
+func NewTypeSet(v []Type) *TypeSet {
+	r := make([]Result, len(v))
+	for i := range v {
+		r[i] = &v[i]
+	}
+	return &TypeSet{
+		resultSet: resultSet{
+			results: r,
+			columns: []string{
+				"Schema",
+				"Name",
+				"Internal name",
+				"Size",
+				"Elements",
+				"Description",
+			},
+		},
+	}
+}
+
+func (t TypeSet) Get() *Type {
+	return t.results[t.current-1].(*Type)
+}
+
+type Type struct {
+	Catalog      string
+	Schema       string
+	Name         string
+	InternalName string
+	Size         string
+	Elements     string
+	Comment      string
+}
+
+func (t Type) Values() []interface{} {
+	return []interface{}{
+		t.Schema,
+		t.Name,
+		t.InternalName,
+		t.Size,
+		t.Elements,
+		t.Comment,
+	}
+}
+
 type PrivilegeSummarySet struct {
 	resultSet
 }
@@ -0,0 +1,177 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gsmate/internal/logger"
+
+	pq "gitee.com/opengauss/openGauss-connector-go-pq"
+	"github.com/pkg/errors"
+)
+
+// noticeQueue holds the notices of the server while a result is being
+// rendered, so that they are printed between results rather than inside.
+type noticeQueue struct {
+	mu      sync.Mutex
+	held    bool
+	pending []*pq.Error
+}
+
+// handleNotice prints the notice e of the server, or queues it while held.
+func (c *DBClient) handleNotice(e *pq.Error) {
+	c.notices.mu.Lock()
+	defer c.notices.mu.Unlock()
+	if c.notices.held {
+		c.notices.pending = append(c.notices.pending, e)
+		return
+	}
+	c.printNotice(e)
+}
+
+// holdNotices queues the notices until the returned function is called.
+func (c *DBClient) holdNotices() func() {
+	c.notices.mu.Lock()
+	c.notices.held = true
+	c.notices.mu.Unlock()
+	return func() {
+		c.notices.mu.Lock()
+		defer c.notices.mu.Unlock()
+		for _, e := range c.notices.pending {
+			c.printNotice(e)
+		}
+		c.notices.held, c.notices.pending = false, nil
+	}
+}
+
+func (c *DBClient) printNotice(e *pq.Error) {
+	logger.Print(severityLevel(e.Severity), formatServerMessage(e, c.cfg.Verbosity, ""))
+}
+
+// reportError prints err, raised by the statement q when not empty. Errors
+// of the server are printed with their fields and kept for \errverbose.
+func (c *DBClient) reportError(err error, q string) {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		logger.Error("%v", err)
+		return
+	}
+	c.lastErr, c.lastErrQuery = pqErr, q
+	logger.Print(logger.ErrorLevel, formatServerMessage(pqErr, c.cfg.Verbosity, q))
+}
+
+// ErrVerbose satisfies the metacmd.Handler interface.
+func (c *DBClient) ErrVerbose() error {
+	if c.lastErr == nil {
+		fmt.Fprintln(c.Out(), "There is no previous error.")
+		return nil
+	}
+	fmt.Fprintln(c.Out(), formatServerMessage(c.lastErr, "verbose", c.lastErrQuery))
+	return nil
+}
+
+// severityLevel returns the log level of a server message severity.
+func severityLevel(severity string) logger.LogLevel {
+	switch severity {
+	case "DEBUG", "LOG":
+		return logger.DebugLevel
+	case "INFO", "NOTICE":
+		return logger.InfoLevel
+	case "WARNING":
+		return logger.WarnLevel
+	}
+	return logger.ErrorLevel
+}
+
+// formatServerMessage renders the notice or error e of the server as psql
+// does for verbosity, one of config.Verbosities. The position of an error
+// is pointed at in q, the statement raising it, when not empty.
+func formatServerMessage(e *pq.Error, verbosity, q string) string {
+	var b strings.Builder
+	b.WriteString(e.Severity + ":  ")
+	if verbosity == "verbose" && e.Code != "" {
+		b.WriteString(string(e.Code) + ": ")
+	}
+	b.WriteString(e.Message)
+	if verbosity == "terse" {
+		return b.String()
+	}
+	if pos, err := strconv.Atoi(e.Position); err == nil && q != "" {
+		b.WriteString(positionLines(q, pos))
+	}
+	field := func(name, v string) {
+		if v != "" {
+			fmt.Fprintf(&b, "\n%s:  %s", name, v)
+		}
+	}
+	field("DETAIL", e.Detail)
+	field("HINT", e.Hint)
+	field("QUERY", e.InternalQuery)
+	// like psql, the context of notices is only shown when verbose
+	if verbosity == "verbose" || severityLevel(e.Severity) >= logger.ErrorLevel {
+		field("CONTEXT", e.Where)
+	}
+	if verbosity != "verbose" {
+		return b.String()
+	}
+	field("SCHEMA NAME", e.Schema)
+	field("TABLE NAME", e.Table)
+	field("COLUMN NAME", e.Column)
+	field("DATATYPE NAME", e.DataTypeName)
+	field("CONSTRAINT NAME", e.Constraint)
+	if e.Routine != "" || e.File != "" {
+		location := e.Routine
+		if e.File != "" {
+			location += ", " + e.File + ":" + e.Line
+		}
+		field("LOCATION", location)
+	}
+	return b.String()
+}
+
+// positionLines returns the line of q holding the 1-based character
+// position pos, and a caret under that character, each line preceded by a
+// newline.
+func positionLines(q string, pos int) string {
+	r := []rune(q)
+	if pos < 1 || pos > len(r)+1 {
+		return ""
+	}
+	pos--
+	start, line := 0, 1
+	for i := 0; i < pos && i < len(r); i++ {
+		if r[i] == '\n' {
+			start, line = i+1, line+1
+		}
+	}
+	end := start
+	for end < len(r) && r[end] != '\n' {
+		end++
+	}
+	prefix := fmt.Sprintf("LINE %d: ", line)
+	// keep tabs so that the caret lines up with the text
+	indent := []rune(strings.Repeat(" ", len(prefix)))
+	for _, c := range r[start:pos] {
+		if c != '\t' {
+			c = ' '
+		}
+		indent = append(indent, c)
+	}
+	return "\n" + prefix + strings.TrimRight(string(r[start:end]), "\r") + "\n" + string(indent) + "^"
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"testing"
+
+	pq "gitee.com/opengauss/openGauss-connector-go-pq"
+)
+
+func TestFormatServerMessage(t *testing.T) {
+	e := &pq.Error{
+		Severity: "NOTICE",
+		Code:     "42P07",
+		Message:  `relation "t" already exists, skipping`,
+		Hint:     "Use another name.",
+		Where:    "PL/pgSQL function f() line 3 at RAISE",
+		Routine:  "transformCreateStmt",
+		File:     "parse_utilcmd.cpp",
+		Line:     "245",
+	}
+	tests := []struct {
+		verbosity string
+		exp       string
+	}{
+		{"terse", `NOTICE:  relation "t" already exists, skipping`},
+		{"default", `NOTICE:  relation "t" already exists, skipping
+HINT:  Use another name.`},
+		{"verbose", `NOTICE:  42P07: relation "t" already exists, skipping
+HINT:  Use another name.
+CONTEXT:  PL/pgSQL function f() line 3 at RAISE
+LOCATION:  transformCreateStmt, parse_utilcmd.cpp:245`},
+	}
+	for _, test := range tests {
+		if s := formatServerMessage(e, test.verbosity, ""); s != test.exp {
+			t.Errorf("%s expected:\n%s\ngot:\n%s", test.verbosity, test.exp, s)
+		}
+	}
+}
+
+func TestFormatServerError(t *testing.T) {
+	e := &pq.Error{
+		Severity: "ERROR",
+		Code:     "42601",
+		Message:  `syntax error at or near "form"`,
+		Position: "14",
+		Where:    "SQL statement",
+	}
+	q := "select a, b\n\tform t"
+	exp := `ERROR:  syntax error at or near "form"
+LINE 2: 	form t
+        	^
+CONTEXT:  SQL statement`
+	if s := formatServerMessage(e, "default", q); s != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, s)
+	}
+	exp = `ERROR:  syntax error at or near "form"`
+	if s := formatServerMessage(e, "terse", q); s != exp {
+		t.Errorf("expected:\n%s\ngot:\n%s", exp, s)
+	}
+}
+
+func TestPositionLines(t *testing.T) {
+	tests := []struct {
+		q   string
+		pos int
+		exp string
+	}{
+		{"selec 1", 1, "\nLINE 1: selec 1\n        ^"},
+		{"select 'é', x", 13, "\nLINE 1: select 'é', x\n                    ^"},
+		{"select 1", 9, "\nLINE 1: select 1\n                ^"},
+		{"select 1", 20, ""},
+	}
+	for i, test := range tests {
+		if s := positionLines(test.q, test.pos); s != test.exp {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, s)
+		}
+	}
+}
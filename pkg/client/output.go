@@ -0,0 +1,140 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"gsmate/config"
+	"gsmate/internal/logger"
+	"gsmate/pkg/client/metacmd"
+
+	"github.com/pkg/errors"
+	"github.com/vimiix/pkg/file"
+)
+
+// pipeOutput writes to the standard input of a shell command.
+type pipeOutput struct {
+	io.WriteCloser
+	cmd *exec.Cmd
+}
+
+// Close closes the standard input of the command and waits for it to exit.
+func (p *pipeOutput) Close() error {
+	p.WriteCloser.Close()
+	return p.cmd.Wait()
+}
+
+// openOutput opens target for writing: the shell command following a leading
+// "|", reading from a pipe, or else the file, created or truncated.
+func openOutput(target string) (io.WriteCloser, error) {
+	if command, ok := strings.CutPrefix(target, "|"); ok {
+		cmd := metacmd.ShellCommand(strings.TrimSpace(command))
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		pipe, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, errors.Wrapf(err, "could not run %q", command)
+		}
+		return &pipeOutput{WriteCloser: pipe, cmd: cmd}, nil
+	}
+	f, err := os.Create(file.ExpandHomePath(target))
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// SetOutput satisfies the metacmd.Handler interface.
+func (c *DBClient) SetOutput(target string) error {
+	c.closeOutput()
+	if target == "" {
+		return nil
+	}
+	w, err := openOutput(target)
+	if err != nil {
+		return err
+	}
+	c.out = w
+	return nil
+}
+
+// closeOutput closes the output set by \o, if any, reverting to the standard
+// output.
+func (c *DBClient) closeOutput() {
+	if c.out == nil {
+		return
+	}
+	if err := c.out.Close(); err != nil {
+		logger.Warn("could not close output: %s", err)
+	}
+	c.out = nil
+}
+
+// WriteBuffer satisfies the metacmd.Handler interface.
+func (c *DBClient) WriteBuffer(target string) error {
+	q := c.stmt.String()
+	if q == "" {
+		q = c.lastQuery
+	}
+	w, err := openOutput(target)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, q); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// redirect applies the output options of \g and \gx for a single query,
+// returning the function restoring the previous output and print settings.
+func (c *DBClient) redirect(params map[string]string) (func(), error) {
+	restore := func() {}
+	if params["expanded"] != "" {
+		prev := config.GetPrintConfig()["expanded"]
+		if err := config.SetPrintOption("expanded", params["expanded"]); err != nil {
+			return nil, err
+		}
+		restore = func() {
+			_ = config.SetPrintOption("expanded", prev)
+		}
+	}
+	target := params["pipe"]
+	if target == "" {
+		return restore, nil
+	}
+	w, err := openOutput(target)
+	if err != nil {
+		restore()
+		return nil, err
+	}
+	prevOut, restorePrint := c.out, restore
+	c.out = w
+	return func() {
+		if err := w.Close(); err != nil {
+			logger.Warn("could not close output: %s", err)
+		}
+		c.out = prevOut
+		restorePrint()
+	}, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenOutput(t *testing.T) {
+	dir := t.TempDir()
+	targets := []string{filepath.Join(dir, "file.txt")}
+	if _, err := exec.LookPath("cat"); err == nil {
+		targets = append(targets, fmt.Sprintf("| cat > %s", filepath.Join(dir, "pipe.txt")))
+	}
+	for _, target := range targets {
+		w, err := openOutput(target)
+		if err != nil {
+			t.Fatalf("%s: %v", target, err)
+		}
+		if _, err := fmt.Fprintln(w, "hello"); err != nil {
+			t.Fatalf("%s: %v", target, err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s: %v", target, err)
+		}
+	}
+	for _, name := range []string{"file.txt", "pipe.txt"}[:len(targets)] {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "hello\n" {
+			t.Errorf("%s: expected %q, got %q", name, "hello\n", b)
+		}
+	}
+}
+
+func TestWriteBuffer(t *testing.T) {
+	dir := t.TempDir()
+	c := &DBClient{stmt: NewStmt(nil), lastQuery: "SELECT 1;"}
+	path := filepath.Join(dir, "query.sql")
+	if err := c.WriteBuffer(path); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "SELECT 1;\n" {
+		t.Errorf("expected the previous query, got %q", b)
+	}
+}
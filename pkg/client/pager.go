@@ -0,0 +1,209 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"unicode/utf8"
+
+	"gsmate/internal/logger"
+
+	"github.com/pkg/errors"
+	"github.com/xo/tblfmt"
+	"golang.org/x/term"
+)
+
+// errPagerClosed is returned by the pager writes once the pager exited before
+// reading all the output, to stop rendering the rest.
+var errPagerClosed = errors.New("pager closed")
+
+// pager buffers the output written to it until it no longer fits the
+// terminal, then starts the pager command and streams the output through it.
+// Short output is written to out on Close.
+type pager struct {
+	out     io.Writer
+	cmdline string
+	// always starts the pager on the first write
+	always bool
+	// height and width are the terminal size
+	height, width int
+	// minLines is the least number of lines output to start the pager
+	minLines int
+
+	buf   bytes.Buffer
+	lines int
+	wide  bool
+	cur   int
+	// direct is set when the pager could not be started
+	direct bool
+
+	cmd   *exec.Cmd
+	pipe  io.WriteCloser
+	state *term.State
+	// closed is set when the pager exited early
+	closed bool
+}
+
+// newPager returns a writer paging w as set by \pset pager, pager_min_lines
+// and pager_cmd when it is the standard output of a terminal, else w itself.
+func newPager(w io.Writer, opts map[string]string) io.Writer {
+	mode, cmdline := opts["pager"], strings.TrimSpace(opts["pager_cmd"])
+	if w != io.Writer(os.Stdout) || mode == "off" || cmdline == "" ||
+		!term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return w
+	}
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		logger.Debug("failed to get terminal size: %s", err)
+		return w
+	}
+	minLines, _ := strconv.Atoi(opts["pager_min_lines"])
+	return &pager{
+		out:      os.Stdout,
+		cmdline:  cmdline,
+		always:   mode == "always",
+		height:   height,
+		width:    width,
+		minLines: minLines,
+	}
+}
+
+// Write satisfies the io.Writer interface.
+func (p *pager) Write(b []byte) (int, error) {
+	if p.closed {
+		return 0, errPagerClosed
+	}
+	if p.cmd != nil {
+		return p.writePipe(b)
+	}
+	if p.direct {
+		return p.out.Write(b)
+	}
+	p.buf.Write(b)
+	p.count(b)
+	if !p.always && !(p.lines >= p.minLines && (p.lines >= p.height || p.wide)) {
+		return len(b), nil
+	}
+	w := p.writePipe
+	if err := p.start(); err != nil {
+		// show the output anyway
+		logger.Warn("could not start pager %q: %s", p.cmdline, err)
+		p.direct, w = true, p.out.Write
+	}
+	_, err := w(p.buf.Bytes())
+	p.buf.Reset()
+	return len(b), err
+}
+
+// count tracks the lines and the widest line of the buffered output.
+func (p *pager) count(b []byte) {
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '\n')
+		if i == -1 {
+			p.cur += utf8.RuneCount(b)
+			break
+		}
+		p.cur += utf8.RuneCount(b[:i])
+		if p.cur > p.width {
+			p.wide = true
+		}
+		p.lines, p.cur, b = p.lines+1, 0, b[i+1:]
+	}
+	if p.cur > p.width {
+		p.wide = true
+	}
+}
+
+// start runs the pager command on the terminal, reading the output from a
+// pipe.
+func (p *pager) start() error {
+	args := strings.Fields(p.cmdline)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout, cmd.Stderr = p.out, os.Stderr
+	pipe, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	// the pager may leave the terminal in its own mode when killed
+	if state, err := term.GetState(int(os.Stdin.Fd())); err == nil {
+		p.state = state
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	p.cmd, p.pipe = cmd, pipe
+	return nil
+}
+
+// writePipe writes b to the running pager, reporting errPagerClosed once it
+// exited.
+func (p *pager) writePipe(b []byte) (int, error) {
+	n, err := p.pipe.Write(b)
+	if err != nil {
+		if errors.Is(err, syscall.EPIPE) || errors.Is(err, os.ErrClosed) {
+			p.closed = true
+			return n, errPagerClosed
+		}
+		return n, err
+	}
+	return n, nil
+}
+
+// Close writes the buffered output when the pager was not needed, or waits
+// for the pager to exit and restores the terminal.
+func (p *pager) Close() error {
+	if p.cmd == nil {
+		_, err := p.out.Write(p.buf.Bytes())
+		p.buf.Reset()
+		return err
+	}
+	p.pipe.Close()
+	err := p.cmd.Wait()
+	if p.state != nil {
+		if err := term.Restore(int(os.Stdin.Fd()), p.state); err != nil {
+			logger.Debug("failed to restore terminal: %s", err)
+		}
+	}
+	p.cmd = nil
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// eg killed by ctrl-C, or quit before reading all the output
+		return nil
+	}
+	return err
+}
+
+// encodePaged renders rs with params to w, through the pager when w is the
+// standard output and the result does not fit the terminal.
+func encodePaged(w io.Writer, rs tblfmt.ResultSet, params map[string]string) error {
+	w = newPager(w, params)
+	err := tblfmt.EncodeAll(w, rs, params)
+	if p, ok := w.(*pager); ok {
+		if cerr := p.Close(); err == nil {
+			err = cerr
+		}
+	}
+	// quitting the pager early is no error
+	if errors.Is(err, errPagerClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestPagerShortOutput(t *testing.T) {
+	var out bytes.Buffer
+	p := &pager{out: &out, cmdline: "false", height: 5, width: 20}
+	for i := 0; i < 4; i++ {
+		if _, err := p.Write([]byte("row\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if p.cmd != nil || out.Len() != 0 {
+		t.Fatalf("expected the output to be buffered, got %q", out.String())
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if exp := strings.Repeat("row\n", 4); out.String() != exp {
+		t.Errorf("expected %q, got %q", exp, out.String())
+	}
+}
+
+func TestPagerCount(t *testing.T) {
+	tests := []struct {
+		s     string
+		lines int
+		wide  bool
+	}{
+		{"abc", 0, false},
+		{"abc\ndef\n", 2, false},
+		{"héllo wörld\n", 1, false},
+		{"0123456789abcdef\n", 1, true},
+		{"0123456789abcdef", 0, true},
+	}
+	for _, test := range tests {
+		p := &pager{width: 12}
+		p.count([]byte(test.s))
+		if p.lines != test.lines || p.wide != test.wide {
+			t.Errorf("%q: expected %d lines, wide %t, got %d, %t", test.s, test.lines, test.wide, p.lines, p.wide)
+		}
+	}
+}
+
+func TestPagerLongOutput(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("no cat command")
+	}
+	var out bytes.Buffer
+	p := &pager{out: &out, cmdline: "cat", height: 5, width: 20, minLines: 2}
+	exp := strings.Repeat("row\n", 10)
+	for i := 0; i < 10; i++ {
+		if _, err := p.Write([]byte("row\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if p.cmd == nil {
+		t.Fatal("expected the pager to be started")
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != exp {
+		t.Errorf("expected %q, got %q", exp, out.String())
+	}
+}
+
+func TestPagerClosedEarly(t *testing.T) {
+	if _, err := exec.LookPath("true"); err != nil {
+		t.Skip("no true command")
+	}
+	p := &pager{out: &bytes.Buffer{}, cmdline: "true", always: true, height: 5, width: 20}
+	line := []byte(strings.Repeat("x", 1023) + "\n")
+	var err error
+	for i := 0; i < 1<<12 && err == nil; i++ {
+		_, err = p.Write(line)
+	}
+	if err != errPagerClosed {
+		t.Fatalf("expected errPagerClosed, got %v", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
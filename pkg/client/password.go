@@ -0,0 +1,166 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"gsmate/internal/errdef"
+	"gsmate/internal/utils"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// sha256Iterations is the default PBKDF2 iteration count of openGauss
+	// sha256 password verifiers.
+	sha256Iterations = 10000
+	// sha256SaltLen is the salt length in bytes of sha256 verifiers.
+	sha256SaltLen = 32
+)
+
+// ChangePassword satisfies the metacmd.Handler interface. It prompts for the
+// new password of user, the current user when empty, and sets it as a
+// verifier computed client-side so that the clear text password is neither
+// sent to the server nor logged there.
+//
+// Users changing their own password must give the current one as well, as
+// openGauss requires it of ordinary users with REPLACE.
+func (c *DBClient) ChangePassword(user string) error {
+	var current string
+	if err := c.DB().QueryRow("SELECT current_user").Scan(&current); err != nil {
+		return err
+	}
+	if user == "" {
+		user = current
+	}
+	var old string
+	if user == current {
+		var err error
+		if old, err = utils.ReadPassword(fmt.Sprintf("Enter current password for user %q: ", user)); err != nil {
+			return err
+		}
+	}
+	password, err := utils.ReadPassword(fmt.Sprintf("Enter new password for user %q: ", user))
+	if err != nil {
+		return err
+	}
+	again, err := utils.ReadPassword("Enter it again: ")
+	if err != nil {
+		return err
+	}
+	if password != again {
+		return errdef.ErrPasswordMismatch
+	}
+
+	salt, err := newSalt()
+	if err != nil {
+		return err
+	}
+	q, err := alterPassword(c.passwordEncryptionType(), user, password, old, salt)
+	if err != nil {
+		return err
+	}
+	_, err = c.DB().Exec(q)
+	return err
+}
+
+// passwordEncryptionType returns the password_encryption_type of the server,
+// md5 (0) when the server has no such setting.
+func (c *DBClient) passwordEncryptionType() string {
+	var typ string
+	if err := c.DB().QueryRow("SHOW password_encryption_type").Scan(&typ); err != nil {
+		return "0"
+	}
+	return typ
+}
+
+// alterPassword returns the statement setting the password of user to the
+// verifier of password for the password_encryption_type typ. The current
+// password old, when not empty, is given with REPLACE.
+func alterPassword(typ, user, password, old string, salt []byte) (string, error) {
+	verifier, err := encryptPassword(typ, password, user, salt)
+	if err != nil {
+		return "", err
+	}
+	q := fmt.Sprintf("ALTER USER %s PASSWORD %s", quoteIdent(user), quoteLiteral(verifier))
+	if old != "" {
+		q += " REPLACE " + quoteLiteral(old)
+	}
+	return q, nil
+}
+
+// encryptPassword returns the verifier of password for the
+// password_encryption_type typ: md5 (0), sha256 followed by md5 (1) or sha256
+// (2), the sha256 ones using salt.
+func encryptPassword(typ, password, user string, salt []byte) (string, error) {
+	switch typ {
+	case "0":
+		return md5Verifier(password, user), nil
+	case "1":
+		return sha256Verifier(password, salt, sha256Iterations) + md5Verifier(password, user), nil
+	case "2":
+		return sha256Verifier(password, salt, sha256Iterations), nil
+	}
+	return "", errors.Wrapf(errdef.ErrNotSupported, "password_encryption_type %s", typ)
+}
+
+// newSalt returns a random salt for sha256 verifiers.
+func newSalt() ([]byte, error) {
+	salt := make([]byte, sha256SaltLen)
+	_, err := rand.Read(salt)
+	return salt, err
+}
+
+// md5Verifier returns the md5 password verifier, "md5" followed by the hex
+// md5 of the password and user name.
+func md5Verifier(password, user string) string {
+	sum := md5.Sum([]byte(password + user))
+	return "md5" + hex.EncodeToString(sum[:])
+}
+
+// sha256Verifier returns the openGauss sha256 password verifier, "sha256"
+// followed by the hex salt, server key and stored key of RFC 5802.
+func sha256Verifier(password string, salt []byte, iterations int) string {
+	key := pbkdf2.Key([]byte(password), salt, iterations, sha256.Size, sha1.New)
+	serverKey := hmacSHA256(key, []byte("Sever Key"))
+	clientKey := hmacSHA256(key, []byte("Client Key"))
+	storedKey := sha256.Sum256(clientKey)
+	return "sha256" + hex.EncodeToString(salt) + hex.EncodeToString(serverKey) + hex.EncodeToString(storedKey[:])
+}
+
+func hmacSHA256(key, msg []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(msg)
+	return mac.Sum(nil)
+}
+
+// quoteIdent quotes s as an SQL identifier.
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// quoteLiteral quotes s as an SQL string literal.
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
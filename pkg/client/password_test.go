@@ -0,0 +1,96 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestMD5Verifier(t *testing.T) {
+	if s, exp := md5Verifier("secret", "omm"), "md5e8ab79bcd445c239f69cb22d2107e1b4"; s != exp {
+		t.Errorf("expected %q, got: %q", exp, s)
+	}
+}
+
+func TestEncryptPassword(t *testing.T) {
+	salt := make([]byte, sha256SaltLen)
+	for i := range salt {
+		salt[i] = byte(i)
+	}
+	md5 := md5Verifier("Gauss@123", "omm")
+	sha := sha256Verifier("Gauss@123", salt, sha256Iterations)
+	// "sha256", the hex salt, server key and stored key
+	if len(sha) != 6+3*64 || !strings.HasPrefix(sha, "sha256"+hex.EncodeToString(salt)) {
+		t.Errorf("unexpected sha256 verifier layout: %q", sha)
+	}
+	tests := []struct {
+		typ, exp string
+	}{
+		{"0", md5},
+		{"1", sha + md5},
+		{"2", sha},
+	}
+	for _, test := range tests {
+		s, err := encryptPassword(test.typ, "Gauss@123", "omm", salt)
+		if err != nil {
+			t.Fatalf("type %s: %v", test.typ, err)
+		}
+		if s != test.exp {
+			t.Errorf("type %s: expected %q, got: %q", test.typ, test.exp, s)
+		}
+	}
+	if _, err := encryptPassword("3", "Gauss@123", "omm", salt); err == nil {
+		t.Error("expected an error for an unknown encryption type")
+	}
+}
+
+func TestAlterPassword(t *testing.T) {
+	salt := make([]byte, sha256SaltLen)
+	tests := []struct {
+		typ, old, exp string
+	}{
+		{"0", "", `ALTER USER "omm" PASSWORD '` + md5Verifier("Gauss@456", "omm") + `'`},
+		{"2", "", `ALTER USER "omm" PASSWORD '` + sha256Verifier("Gauss@456", salt, sha256Iterations) + `'`},
+		{"0", "it's old", `ALTER USER "omm" PASSWORD '` + md5Verifier("Gauss@456", "omm") + `' REPLACE 'it''s old'`},
+		{"1", "Gauss@123", `ALTER USER "omm" PASSWORD '` + sha256Verifier("Gauss@456", salt, sha256Iterations) +
+			md5Verifier("Gauss@456", "omm") + `' REPLACE 'Gauss@123'`},
+	}
+	for _, test := range tests {
+		s, err := alterPassword(test.typ, "omm", "Gauss@456", test.old, salt)
+		if err != nil {
+			t.Fatalf("type %s: %v", test.typ, err)
+		}
+		if s != test.exp {
+			t.Errorf("type %s: expected %s, got: %s", test.typ, test.exp, s)
+		}
+		if strings.Contains(s, "Gauss@456") {
+			t.Errorf("type %s: new password sent in clear text: %s", test.typ, s)
+		}
+	}
+	if _, err := alterPassword("3", "omm", "Gauss@456", "", salt); err == nil {
+		t.Error("expected an error for an unknown encryption type")
+	}
+}
+
+func TestQuote(t *testing.T) {
+	if s, exp := quoteIdent(`my"user`), `"my""user"`; s != exp {
+		t.Errorf("expected %s, got: %s", exp, s)
+	}
+	if s, exp := quoteLiteral("it's"), "'it''s'"; s != exp {
+		t.Errorf("expected %s, got: %s", exp, s)
+	}
+}
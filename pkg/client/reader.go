@@ -0,0 +1,616 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"gsmate/internal/logger"
+	"gsmate/pkg/client/metadata"
+)
+
+// condBuilder collects the WHERE conditions and placeholder values of a
+// catalog query.
+type condBuilder struct {
+	conds []string
+	vals  []any
+}
+
+// add appends a literal condition.
+func (b *condBuilder) add(cond string) {
+	b.conds = append(b.conds, cond)
+}
+
+// like appends a "col LIKE $n" condition when pattern is not empty.
+func (b *condBuilder) like(col, pattern string) {
+	if pattern == "" {
+		return
+	}
+	b.vals = append(b.vals, pattern)
+	b.conds = append(b.conds, fmt.Sprintf("%s LIKE $%d", col, len(b.vals)))
+}
+
+// system excludes the objects of system schemas unless f.WithSystem is set.
+func (b *condBuilder) system(f metadata.Filter, col string) {
+	if !f.WithSystem {
+		b.add(col + " NOT IN ('pg_catalog', 'information_schema') AND " + col + " !~ '^pg_toast'")
+	}
+}
+
+func (c *DBClient) Schemas(f metadata.Filter) (*metadata.SchemaSet, error) {
+	qstr := `SELECT n.nspname as "Name",
+  pg_catalog.current_database() as "Catalog",
+  pg_catalog.pg_get_userbyid(n.nspowner) as "Owner",
+  COALESCE(pg_catalog.array_to_string(n.nspacl, E'\n'), '') as "Access privileges",
+  COALESCE(pg_catalog.obj_description(n.oid, 'pg_namespace'), '') as "Description"
+FROM pg_catalog.pg_namespace n`
+	b := &condBuilder{}
+	if !f.WithSystem {
+		b.add("n.nspname !~ '^pg_' AND n.nspname <> 'information_schema'")
+	}
+	b.like("n.nspname", f.Name)
+	rows, closeFunc, err := c.Query(qstr, b.conds, "1", b.vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFunc()
+
+	results := []metadata.Schema{}
+	for rows.Next() {
+		rec := metadata.Schema{}
+		err = rows.Scan(&rec.Schema, &rec.Catalog, &rec.Owner, &rec.AccessPrivileges, &rec.Comment)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewSchemaSet(results), nil
+}
+
+// Columns reads the columns of relations, f.Parent filters by relation name.
+func (c *DBClient) Columns(f metadata.Filter) (*metadata.ColumnSet, error) {
+	qstr := `SELECT pg_catalog.current_database() as "Catalog",
+  n.nspname as "Schema",
+  c.relname as "Table",
+  a.attname as "Name",
+  a.attnum as "Ordinal position",
+  pg_catalog.format_type(a.atttypid, a.atttypmod) as "Type",
+  COALESCE(pg_catalog.pg_get_expr(d.adbin, d.adrelid), '') as "Default",
+  CASE WHEN a.atttypid IN (1042, 1043, 3969) AND a.atttypmod > 0 THEN a.atttypmod - 4
+       WHEN a.atttypid = 1700 AND a.atttypmod > 0 THEN ((a.atttypmod - 4) >> 16) & 65535
+       ELSE 0 END as "Size",
+  CASE WHEN a.atttypid = 1700 AND a.atttypmod > 0 THEN (a.atttypmod - 4) & 65535
+       ELSE 0 END as "Decimal digits",
+  CASE WHEN a.atttypid IN (20, 21, 23, 700, 701) THEN 2
+       WHEN a.atttypid = 1700 THEN 10
+       ELSE 0 END as "Precision radix",
+  CASE WHEN a.atttypid IN (1042, 1043, 3969) AND a.atttypmod > 0
+       THEN (a.atttypmod - 4) * pg_catalog.pg_encoding_max_length(pg_catalog.pg_char_to_encoding(pg_catalog.getdatabaseencoding()))
+       ELSE 0 END as "Octet length",
+  CASE WHEN a.attnotnull THEN 'NO' ELSE 'YES' END as "Nullable",
+  CASE a.attstorage WHEN 'p' THEN 'plain' WHEN 'm' THEN 'main' WHEN 'x' THEN 'extended' WHEN 'e' THEN 'external' ELSE '' END as "Storage",
+  COALESCE(pg_catalog.col_description(c.oid, a.attnum), '') as "Description"
+FROM pg_catalog.pg_attribute a
+     JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+     JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+     LEFT JOIN pg_catalog.pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum`
+	b := &condBuilder{}
+	b.add("a.attnum > 0 AND NOT a.attisdropped")
+	if f.OnlyVisible {
+		b.add("pg_catalog.pg_table_is_visible(c.oid)")
+	}
+	b.system(f, "n.nspname")
+	b.like("n.nspname", f.Schema)
+	b.like("c.relname", f.Parent)
+	b.like("a.attname", f.Name)
+	rows, closeFunc, err := c.Query(qstr, b.conds, "2, 3, 5", b.vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFunc()
+
+	results := []metadata.Column{}
+	for rows.Next() {
+		rec := metadata.Column{}
+		err = rows.Scan(&rec.Catalog, &rec.Schema, &rec.Table, &rec.Name, &rec.OrdinalPosition,
+			&rec.DataType, &rec.Default, &rec.ColumnSize, &rec.DecimalDigits, &rec.NumPrecRadix,
+			&rec.CharOctetLength, &rec.IsNullable, &rec.Storage, &rec.Comment)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewColumnSet(results), nil
+}
+
+// Indexes reads indexes, f.Parent filters by the indexed table name.
+func (c *DBClient) Indexes(f metadata.Filter) (*metadata.IndexSet, error) {
+	qstr := `SELECT pg_catalog.current_database() as "Catalog",
+  n.nspname as "Schema",
+  t.relname as "Table",
+  c.relname as "Name",
+  CASE WHEN i.indisprimary THEN 'YES' ELSE 'NO' END as "Is primary",
+  CASE WHEN i.indisunique THEN 'YES' ELSE 'NO' END as "Is unique",
+  am.amname as "Type",
+  pg_catalog.array_to_string(ARRAY(
+    SELECT pg_catalog.pg_get_indexdef(i.indexrelid, k, true)
+    FROM pg_catalog.generate_series(1, i.indnatts) k
+  ), ', ') as "Columns",
+  CASE WHEN c.relkind = 'I' THEN 'GLOBAL'
+       WHEN t.parttype IN ('p', 's') THEN 'LOCAL'
+       ELSE '' END as "Scope"
+FROM pg_catalog.pg_index i
+     JOIN pg_catalog.pg_class c ON c.oid = i.indexrelid
+     JOIN pg_catalog.pg_class t ON t.oid = i.indrelid
+     JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+     JOIN pg_catalog.pg_am am ON am.oid = c.relam`
+	b := &condBuilder{}
+	if f.OnlyVisible {
+		b.add("pg_catalog.pg_table_is_visible(c.oid)")
+	}
+	b.system(f, "n.nspname")
+	b.like("n.nspname", f.Schema)
+	b.like("t.relname", f.Parent)
+	b.like("c.relname", f.Name)
+	rows, closeFunc, err := c.Query(qstr, b.conds, "2, 3, i.indisprimary DESC, i.indisunique DESC, 4", b.vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFunc()
+
+	results := []metadata.Index{}
+	for rows.Next() {
+		rec := metadata.Index{}
+		err = rows.Scan(&rec.Catalog, &rec.Schema, &rec.Table, &rec.Name,
+			&rec.IsPrimary, &rec.IsUnique, &rec.Type, &rec.Columns, &rec.Scope)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewIndexSet(results), nil
+}
+
+// funcTypes maps the function types accepted in metadata.Filter.Types to
+// the values of the "Type" column read by Functions.
+var funcTypes = map[string]string{
+	"FUNCTION":  "func",
+	"PROCEDURE": "proc",
+	"AGGREGATE": "agg",
+	"WINDOW":    "window",
+	"TRIGGER":   "trigger",
+}
+
+// funcTypes restricts expr to the function types in types; unknown types
+// match nothing.
+func (b *condBuilder) funcTypes(expr string, types []string) {
+	if len(types) == 0 {
+		return
+	}
+	pholders := []string{"''"}
+	for _, t := range types {
+		if v, ok := funcTypes[t]; ok {
+			b.vals = append(b.vals, v)
+			pholders = append(pholders, fmt.Sprintf("$%d", len(b.vals)))
+		}
+	}
+	b.add(fmt.Sprintf("%s IN (%s)", expr, strings.Join(pholders, ", ")))
+}
+
+// Functions reads functions and procedures, including the ones declared in
+// packages; f.Parent filters by package name and overloaded functions are
+// returned once per signature.
+func (c *DBClient) Functions(f metadata.Filter) (*metadata.FunctionSet, error) {
+	procKind := ""
+	if c.hasCatalogColumn("pg_proc", "prokind") {
+		procKind = "WHEN p.prokind = 'p' THEN 'proc'\n       "
+	}
+	typeExpr := `CASE ` + procKind + `WHEN p.proisagg THEN 'agg'
+       WHEN p.proiswindow THEN 'window'
+       WHEN p.prorettype = 'pg_catalog.trigger'::pg_catalog.regtype THEN 'trigger'
+       ELSE 'func' END`
+	pkgExpr, pkgJoin := "''", ""
+	if c.hasCatalogColumn("pg_proc", "packageid") {
+		pkgExpr = "COALESCE(pkg.pkgname, '')"
+		pkgJoin = "\n     LEFT JOIN pg_catalog.gs_package pkg ON pkg.oid = p.packageid"
+	}
+	qstr := `SELECT pg_catalog.current_database() as "Catalog",
+  n.nspname as "Schema",
+  p.proname as "Name",
+  pg_catalog.pg_get_function_result(p.oid) as "Result data type",
+  pg_catalog.pg_get_function_arguments(p.oid) as "Argument data types",
+  ` + typeExpr + ` as "Type",
+  CASE p.provolatile WHEN 'i' THEN 'immutable' WHEN 's' THEN 'stable' WHEN 'v' THEN 'volatile' ELSE '' END as "Volatility",
+  CASE WHEN p.prosecdef THEN 'definer' ELSE 'invoker' END as "Security",
+  COALESCE(l.lanname, '') as "Language",
+  COALESCE(p.prosrc, '') as "Source code",
+  ` + pkgExpr + ` as "Package",
+  p.proname || '_' || p.oid as "Specific name"
+FROM pg_catalog.pg_proc p
+     LEFT JOIN pg_catalog.pg_namespace n ON n.oid = p.pronamespace
+     LEFT JOIN pg_catalog.pg_language l ON l.oid = p.prolang` + pkgJoin
+	b := &condBuilder{}
+	if f.OnlyVisible {
+		b.add("pg_catalog.pg_function_is_visible(p.oid)")
+	}
+	b.system(f, "n.nspname")
+	b.like("n.nspname", f.Schema)
+	b.like("p.proname", f.Name)
+	b.like(pkgExpr, f.Parent)
+	b.funcTypes(typeExpr, f.Types)
+	rows, closeFunc, err := c.Query(qstr, b.conds, "2, 11, 3, 5, p.oid", b.vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFunc()
+
+	results := []metadata.Function{}
+	for rows.Next() {
+		rec := metadata.Function{}
+		err = rows.Scan(&rec.Catalog, &rec.Schema, &rec.Name, &rec.ResultType, &rec.ArgTypes, &rec.Type,
+			&rec.Volatility, &rec.Security, &rec.Language, &rec.Source, &rec.Package, &rec.SpecificName)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewFunctionSet(results), nil
+}
+
+// FunctionColumns reads the arguments of functions, f.Parent filters by
+// function name or specific name so that a single overload can be selected.
+func (c *DBClient) FunctionColumns(f metadata.Filter) (*metadata.FunctionColumnSet, error) {
+	qstr := `SELECT pg_catalog.current_database() as "Catalog",
+  n.nspname as "Schema",
+  p.proname as "Function name",
+  p.proname || '_' || p.oid as "Specific name",
+  COALESCE(p.proargnames[k.i], '') as "Name",
+  k.i as "Ordinal position",
+  CASE COALESCE(p.proargmodes[k.i], 'i') WHEN 'i' THEN 'IN' WHEN 'o' THEN 'OUT' WHEN 'b' THEN 'INOUT'
+       WHEN 'v' THEN 'VARIADIC' WHEN 't' THEN 'TABLE' ELSE '' END as "Type",
+  pg_catalog.format_type(COALESCE(p.proallargtypes[k.i], p.proargtypes[k.i - 1]), NULL) as "Data type"
+FROM pg_catalog.pg_proc p
+     JOIN pg_catalog.pg_namespace n ON n.oid = p.pronamespace
+     CROSS JOIN pg_catalog.generate_series(1, COALESCE(pg_catalog.array_length(p.proallargtypes, 1), p.pronargs)) k(i)`
+	b := &condBuilder{}
+	if f.OnlyVisible {
+		b.add("pg_catalog.pg_function_is_visible(p.oid)")
+	}
+	b.system(f, "n.nspname")
+	b.like("n.nspname", f.Schema)
+	if f.Parent != "" {
+		b.vals = append(b.vals, f.Parent)
+		b.add(fmt.Sprintf("(p.proname LIKE $%d OR p.proname || '_' || p.oid LIKE $%d)", len(b.vals), len(b.vals)))
+	}
+	b.like("COALESCE(p.proargnames[k.i], '')", f.Name)
+	rows, closeFunc, err := c.Query(qstr, b.conds, "2, 3, 4, 6", b.vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFunc()
+
+	results := []metadata.FunctionColumn{}
+	for rows.Next() {
+		rec := metadata.FunctionColumn{}
+		var specificName string
+		err = rows.Scan(&rec.Catalog, &rec.Schema, &rec.FunctionName, &specificName, &rec.Name,
+			&rec.OrdinalPosition, &rec.Type, &rec.DataType)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewFunctionColumnSet(results), nil
+}
+
+// IndexColumns reads the key columns and expressions of indexes, f.Parent
+// filters by table name and f.Name by index name.
+func (c *DBClient) IndexColumns(f metadata.Filter) (*metadata.IndexColumnSet, error) {
+	qstr := `SELECT pg_catalog.current_database() as "Catalog",
+  n.nspname as "Schema",
+  t.relname as "Table",
+  c.relname as "Index name",
+  pg_catalog.pg_get_indexdef(i.indexrelid, k.i, true) as "Name",
+  pg_catalog.format_type(a.atttypid, a.atttypmod) as "Data type",
+  k.i as "Ordinal position"
+FROM pg_catalog.pg_index i
+     JOIN pg_catalog.pg_class c ON c.oid = i.indexrelid
+     JOIN pg_catalog.pg_class t ON t.oid = i.indrelid
+     JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+     CROSS JOIN pg_catalog.generate_series(1, i.indnatts) k(i)
+     JOIN pg_catalog.pg_attribute a ON a.attrelid = i.indexrelid AND a.attnum = k.i`
+	b := &condBuilder{}
+	if f.OnlyVisible {
+		b.add("pg_catalog.pg_table_is_visible(c.oid)")
+	}
+	b.system(f, "n.nspname")
+	b.like("n.nspname", f.Schema)
+	b.like("t.relname", f.Parent)
+	b.like("c.relname", f.Name)
+	rows, closeFunc, err := c.Query(qstr, b.conds, "2, 3, 4, 7", b.vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFunc()
+
+	results := []metadata.IndexColumn{}
+	for rows.Next() {
+		rec := metadata.IndexColumn{}
+		err = rows.Scan(&rec.Catalog, &rec.Schema, &rec.Table, &rec.IndexName, &rec.Name,
+			&rec.DataType, &rec.OrdinalPosition)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewIndexColumnSet(results), nil
+}
+
+// constraintFrom is the FROM clause shared by the constraint readers, it
+// resolves the referenced table and the unique constraint backing a foreign
+// key.
+const constraintFrom = `
+FROM pg_catalog.pg_constraint con
+     JOIN pg_catalog.pg_class t ON t.oid = con.conrelid
+     JOIN pg_catalog.pg_namespace n ON n.oid = t.relnamespace
+     LEFT JOIN pg_catalog.pg_class ft ON ft.oid = con.confrelid
+     LEFT JOIN pg_catalog.pg_namespace fn ON fn.oid = ft.relnamespace
+     LEFT JOIN pg_catalog.pg_constraint fc ON fc.conrelid = con.confrelid
+          AND fc.conindid = con.conindid AND fc.contype IN ('p', 'u')`
+
+// conTypeExpr names the type of a constraint.
+const conTypeExpr = `CASE con.contype WHEN 'c' THEN 'CHECK' WHEN 'f' THEN 'FOREIGN KEY' WHEN 'p' THEN 'PRIMARY KEY'
+       WHEN 'u' THEN 'UNIQUE' WHEN 't' THEN 'TRIGGER' WHEN 'x' THEN 'EXCLUDE' ELSE '' END`
+
+// Constraints reads table constraints, f.Parent filters by table name and
+// f.Reference by the name of the table referenced by foreign keys.
+func (c *DBClient) Constraints(f metadata.Filter) (*metadata.ConstraintSet, error) {
+	qstr := `SELECT pg_catalog.current_database() as "Catalog",
+  n.nspname as "Schema",
+  t.relname as "Table",
+  con.conname as "Name",
+  ` + conTypeExpr + ` as "Type",
+  CASE WHEN con.condeferrable THEN 'YES' ELSE 'NO' END as "Is deferrable",
+  CASE WHEN con.condeferred THEN 'YES' ELSE 'NO' END as "Initially deferred",
+  CASE WHEN con.contype = 'f' THEN pg_catalog.current_database() ELSE '' END as "Foreign catalog",
+  COALESCE(fn.nspname, '') as "Foreign schema",
+  COALESCE(ft.relname, '') as "Foreign table",
+  COALESCE(fc.conname, '') as "Foreign name",
+  CASE con.confmatchtype WHEN 'f' THEN 'FULL' WHEN 'p' THEN 'PARTIAL' WHEN 's' THEN 'SIMPLE' WHEN 'u' THEN 'SIMPLE' ELSE '' END as "Match type",
+  CASE con.confupdtype WHEN 'a' THEN 'NO ACTION' WHEN 'r' THEN 'RESTRICT' WHEN 'c' THEN 'CASCADE'
+       WHEN 'n' THEN 'SET NULL' WHEN 'd' THEN 'SET DEFAULT' ELSE '' END as "Update rule",
+  CASE con.confdeltype WHEN 'a' THEN 'NO ACTION' WHEN 'r' THEN 'RESTRICT' WHEN 'c' THEN 'CASCADE'
+       WHEN 'n' THEN 'SET NULL' WHEN 'd' THEN 'SET DEFAULT' ELSE '' END as "Delete rule",
+  CASE WHEN con.contype = 'c' THEN pg_catalog.pg_get_constraintdef(con.oid, true) ELSE '' END as "Check clause"` + constraintFrom
+	b := &condBuilder{}
+	if f.OnlyVisible {
+		b.add("pg_catalog.pg_table_is_visible(t.oid)")
+	}
+	b.system(f, "n.nspname")
+	b.like("n.nspname", f.Schema)
+	b.like("t.relname", f.Parent)
+	b.like("con.conname", f.Name)
+	b.like("ft.relname", f.Reference)
+	if len(f.Types) != 0 {
+		pholders := []string{"''"}
+		for _, t := range f.Types {
+			b.vals = append(b.vals, t)
+			pholders = append(pholders, fmt.Sprintf("$%d", len(b.vals)))
+		}
+		b.add(fmt.Sprintf("%s IN (%s)", conTypeExpr, strings.Join(pholders, ", ")))
+	}
+	rows, closeFunc, err := c.Query(qstr, b.conds, "2, 3, 4", b.vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFunc()
+
+	results := []metadata.Constraint{}
+	for rows.Next() {
+		rec := metadata.Constraint{}
+		err = rows.Scan(&rec.Catalog, &rec.Schema, &rec.Table, &rec.Name, &rec.Type,
+			&rec.IsDeferrable, &rec.IsInitiallyDeferred, &rec.ForeignCatalog, &rec.ForeignSchema,
+			&rec.ForeignTable, &rec.ForeignName, &rec.MatchType, &rec.UpdateRule, &rec.DeleteRule,
+			&rec.CheckClause)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewConstraintSet(results), nil
+}
+
+// ConstraintColumns reads the columns of table constraints, paired with the
+// referenced columns for foreign keys. Filters are the same as Constraints.
+func (c *DBClient) ConstraintColumns(f metadata.Filter) (*metadata.ConstraintColumnSet, error) {
+	qstr := `SELECT pg_catalog.current_database() as "Catalog",
+  n.nspname as "Schema",
+  t.relname as "Table",
+  con.conname as "Constraint",
+  a.attname as "Name",
+  k.i as "Ordinal position",
+  CASE WHEN con.contype = 'f' THEN pg_catalog.current_database() ELSE '' END as "Foreign catalog",
+  COALESCE(fn.nspname, '') as "Foreign schema",
+  COALESCE(ft.relname, '') as "Foreign table",
+  COALESCE(fc.conname, '') as "Foreign constraint",
+  COALESCE(fa.attname, '') as "Foreign name"` + constraintFrom + `
+     CROSS JOIN pg_catalog.generate_series(1, pg_catalog.array_length(con.conkey, 1)) k(i)
+     JOIN pg_catalog.pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = con.conkey[k.i]
+     LEFT JOIN pg_catalog.pg_attribute fa ON fa.attrelid = con.confrelid AND fa.attnum = con.confkey[k.i]`
+	b := &condBuilder{}
+	if f.OnlyVisible {
+		b.add("pg_catalog.pg_table_is_visible(t.oid)")
+	}
+	b.system(f, "n.nspname")
+	b.like("n.nspname", f.Schema)
+	b.like("t.relname", f.Parent)
+	b.like("con.conname", f.Name)
+	b.like("ft.relname", f.Reference)
+	rows, closeFunc, err := c.Query(qstr, b.conds, "2, 3, 4, 6", b.vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFunc()
+
+	results := []metadata.ConstraintColumn{}
+	for rows.Next() {
+		rec := metadata.ConstraintColumn{}
+		err = rows.Scan(&rec.Catalog, &rec.Schema, &rec.Table, &rec.Constraint, &rec.Name,
+			&rec.OrdinalPosition, &rec.ForeignCatalog, &rec.ForeignSchema, &rec.ForeignTable,
+			&rec.ForeignConstraint, &rec.ForeignName)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewConstraintColumnSet(results), nil
+}
+
+// Sequences reads sequences, including openGauss large sequences.
+func (c *DBClient) Sequences(f metadata.Filter) (*metadata.SequenceSet, error) {
+	qstr := `SELECT pg_catalog.current_database() as "Catalog",
+  n.nspname as "Schema",
+  c.relname as "Name",
+  CASE c.relkind WHEN 'L' THEN 'int16' ELSE 'bigint' END as "Type",
+  (pg_catalog.pg_sequence_parameters(c.oid)).start_value::pg_catalog.text as "Start",
+  (pg_catalog.pg_sequence_parameters(c.oid)).minimum_value::pg_catalog.text as "Min",
+  (pg_catalog.pg_sequence_parameters(c.oid)).maximum_value::pg_catalog.text as "Max",
+  (pg_catalog.pg_sequence_parameters(c.oid)).increment::pg_catalog.text as "Increment",
+  CASE WHEN (pg_catalog.pg_sequence_parameters(c.oid)).cycle_option THEN 'YES' ELSE 'NO' END as "Cycles?"
+FROM pg_catalog.pg_class c
+     JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace`
+	b := &condBuilder{}
+	b.add("c.relkind IN ('S', 'L')")
+	if f.OnlyVisible {
+		b.add("pg_catalog.pg_table_is_visible(c.oid)")
+	}
+	b.system(f, "n.nspname")
+	b.like("n.nspname", f.Schema)
+	b.like("c.relname", f.Name)
+	rows, closeFunc, err := c.Query(qstr, b.conds, "2, 3", b.vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFunc()
+
+	results := []metadata.Sequence{}
+	for rows.Next() {
+		rec := metadata.Sequence{}
+		err = rows.Scan(&rec.Catalog, &rec.Schema, &rec.Name, &rec.DataType, &rec.Start,
+			&rec.Min, &rec.Max, &rec.Increment, &rec.Cycles)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewSequenceSet(results), nil
+}
+
+// hasCatalogColumn reports whether the pg_catalog relation rel has column
+// col, which differs between openGauss releases. Results are cached for the
+// lifetime of the client.
+func (c *DBClient) hasCatalogColumn(rel, col string) bool {
+	key := rel + "." + col
+	if ok, found := c.catalogColumns[key]; found {
+		return ok
+	}
+	var n int
+	err := c.DB().QueryRow(`SELECT pg_catalog.count(*) FROM pg_catalog.pg_attribute
+WHERE attrelid = ('pg_catalog.' || $1)::pg_catalog.regclass AND attname = $2 AND NOT attisdropped`, rel, col).Scan(&n)
+	if err != nil {
+		logger.Debug("check catalog column %s: %v", key, err)
+		return false
+	}
+	if c.catalogColumns == nil {
+		c.catalogColumns = map[string]bool{}
+	}
+	c.catalogColumns[key] = n != 0
+	return n != 0
+}
+
+// Types reads the user visible data types, skipping array and non composite
+// relation row types.
+func (c *DBClient) Types(f metadata.Filter) (*metadata.TypeSet, error) {
+	qstr := `SELECT pg_catalog.current_database() as "Catalog",
+  n.nspname as "Schema",
+  pg_catalog.format_type(t.oid, NULL) as "Name",
+  t.typname as "Internal name",
+  CASE WHEN t.typrelid != 0 THEN 'tuple'
+       WHEN t.typlen < 0 THEN 'var'
+       ELSE t.typlen::pg_catalog.text END as "Size",
+  COALESCE(pg_catalog.array_to_string(ARRAY(
+    SELECT e.enumlabel FROM pg_catalog.pg_enum e
+    WHERE e.enumtypid = t.oid ORDER BY e.enumsortorder
+  ), E'\n'), '') as "Elements",
+  COALESCE(pg_catalog.obj_description(t.oid, 'pg_type'), '') as "Description"
+FROM pg_catalog.pg_type t
+     LEFT JOIN pg_catalog.pg_namespace n ON n.oid = t.typnamespace`
+	b := &condBuilder{}
+	b.add("(t.typrelid = 0 OR (SELECT c.relkind = 'c' FROM pg_catalog.pg_class c WHERE c.oid = t.typrelid))")
+	b.add("NOT EXISTS(SELECT 1 FROM pg_catalog.pg_type el WHERE el.oid = t.typelem AND el.typarray = t.oid)")
+	if f.OnlyVisible {
+		b.add("pg_catalog.pg_type_is_visible(t.oid)")
+	}
+	b.system(f, "n.nspname")
+	b.like("n.nspname", f.Schema)
+	b.like("t.typname", f.Name)
+	rows, closeFunc, err := c.Query(qstr, b.conds, "2, 3", b.vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFunc()
+
+	results := []metadata.Type{}
+	for rows.Next() {
+		rec := metadata.Type{}
+		err = rows.Scan(&rec.Catalog, &rec.Schema, &rec.Name, &rec.InternalName, &rec.Size, &rec.Elements, &rec.Comment)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rec)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return metadata.NewTypeSet(results), nil
+}
@@ -0,0 +1,246 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+
+	"gsmate/config"
+	"gsmate/pkg/client/metadata"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// catalogConn is a driver connection recording the catalog queries of the
+// readers, which all return no rows. Probes of optional catalog columns
+// are answered from columns.
+type catalogConn struct {
+	columns map[string]bool
+	probes  int
+	query   string
+	args    []any
+}
+
+func (c *catalogConn) Connect(context.Context) (driver.Conn, error) { return c, nil }
+func (c *catalogConn) Driver() driver.Driver                        { return nil }
+func (c *catalogConn) Prepare(string) (driver.Stmt, error)          { return nil, driver.ErrSkip }
+func (c *catalogConn) Close() error                                 { return nil }
+func (c *catalogConn) Begin() (driver.Tx, error)                    { return nil, driver.ErrSkip }
+
+func (c *catalogConn) QueryContext(_ context.Context, q string, args []driver.NamedValue) (driver.Rows, error) {
+	vals := make([]any, len(args))
+	for i, a := range args {
+		vals[i] = a.Value
+	}
+	if strings.HasPrefix(q, "SELECT pg_catalog.count(*) FROM pg_catalog.pg_attribute") {
+		c.probes++
+		var n int64
+		if c.columns[vals[0].(string)+"."+vals[1].(string)] {
+			n = 1
+		}
+		return &catalogRows{cols: []string{"count"}, rows: [][]driver.Value{{n}}}, nil
+	}
+	c.query, c.args = q, nil
+	if len(vals) != 0 {
+		c.args = vals
+	}
+	return &catalogRows{}, nil
+}
+
+type catalogRows struct {
+	cols []string
+	rows [][]driver.Value
+}
+
+func (r *catalogRows) Columns() []string { return r.cols }
+func (r *catalogRows) Close() error      { return nil }
+
+func (r *catalogRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func newCatalogClient(t *testing.T, conn *catalogConn) *DBClient {
+	db := sql.OpenDB(conn)
+	sc, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &DBClient{cfg: &config.Config{}, db: db, conn: sc}
+}
+
+// whereClause returns the conditions the readers add to their query.
+func whereClause(q string) string {
+	i := strings.LastIndex(q, "\nWHERE ")
+	if i < 0 {
+		return ""
+	}
+	q = q[i+len("\nWHERE "):]
+	if j := strings.LastIndex(q, "\nORDER BY "); j >= 0 {
+		q = q[:j]
+	}
+	return q
+}
+
+func TestReaderConditions(t *testing.T) {
+	const sys = "n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname !~ '^pg_toast'"
+	schemas := func(c *DBClient, f metadata.Filter) error { _, err := c.Schemas(f); return err }
+	columns := func(c *DBClient, f metadata.Filter) error { _, err := c.Columns(f); return err }
+	indexes := func(c *DBClient, f metadata.Filter) error { _, err := c.Indexes(f); return err }
+	indexColumns := func(c *DBClient, f metadata.Filter) error { _, err := c.IndexColumns(f); return err }
+	constraints := func(c *DBClient, f metadata.Filter) error { _, err := c.Constraints(f); return err }
+	constraintColumns := func(c *DBClient, f metadata.Filter) error { _, err := c.ConstraintColumns(f); return err }
+	functionColumns := func(c *DBClient, f metadata.Filter) error { _, err := c.FunctionColumns(f); return err }
+	sequences := func(c *DBClient, f metadata.Filter) error { _, err := c.Sequences(f); return err }
+	types := func(c *DBClient, f metadata.Filter) error { _, err := c.Types(f); return err }
+
+	tests := []struct {
+		name   string
+		read   func(*DBClient, metadata.Filter) error
+		filter metadata.Filter
+		where  string
+		args   []any
+	}{
+		{"schemas", schemas, metadata.Filter{},
+			"n.nspname !~ '^pg_' AND n.nspname <> 'information_schema'", nil},
+		{"schemas", schemas, metadata.Filter{Name: "pub%", WithSystem: true},
+			"n.nspname LIKE $1", []any{"pub%"}},
+		{"columns", columns, metadata.Filter{Schema: "public", Parent: "t", Name: "id"},
+			"a.attnum > 0 AND NOT a.attisdropped AND " + sys + " AND n.nspname LIKE $1 AND c.relname LIKE $2 AND a.attname LIKE $3",
+			[]any{"public", "t", "id"}},
+		{"columns", columns, metadata.Filter{Parent: "t", WithSystem: true, OnlyVisible: true},
+			"a.attnum > 0 AND NOT a.attisdropped AND pg_catalog.pg_table_is_visible(c.oid) AND c.relname LIKE $1",
+			[]any{"t"}},
+		{"indexes", indexes, metadata.Filter{Parent: "t%", Name: "t\\_pkey"},
+			sys + " AND t.relname LIKE $1 AND c.relname LIKE $2", []any{"t%", "t\\_pkey"}},
+		{"indexes", indexes, metadata.Filter{WithSystem: true}, "", nil},
+		{"index columns", indexColumns, metadata.Filter{Schema: "s", Name: "i", OnlyVisible: true},
+			"pg_catalog.pg_table_is_visible(c.oid) AND " + sys + " AND n.nspname LIKE $1 AND c.relname LIKE $2",
+			[]any{"s", "i"}},
+		{"constraints", constraints, metadata.Filter{Parent: "t", Types: []string{"PRIMARY KEY", "UNIQUE"}},
+			sys + " AND t.relname LIKE $1 AND " + conTypeExpr + " IN ('', $2, $3)",
+			[]any{"t", "PRIMARY KEY", "UNIQUE"}},
+		{"constraints", constraints, metadata.Filter{Reference: "t", WithSystem: true, OnlyVisible: true},
+			"pg_catalog.pg_table_is_visible(t.oid) AND ft.relname LIKE $1", []any{"t"}},
+		{"constraint columns", constraintColumns, metadata.Filter{Schema: "s", Parent: "t", Name: "c", WithSystem: true},
+			"n.nspname LIKE $1 AND t.relname LIKE $2 AND con.conname LIKE $3", []any{"s", "t", "c"}},
+		{"function columns", functionColumns, metadata.Filter{Parent: "f_16384", Name: "a%"},
+			sys + " AND (p.proname LIKE $1 OR p.proname || '_' || p.oid LIKE $1) AND COALESCE(p.proargnames[k.i], '') LIKE $2",
+			[]any{"f_16384", "a%"}},
+		{"sequences", sequences, metadata.Filter{Schema: "s", Name: "seq", OnlyVisible: true},
+			"c.relkind IN ('S', 'L') AND pg_catalog.pg_table_is_visible(c.oid) AND " + sys + " AND n.nspname LIKE $1 AND c.relname LIKE $2",
+			[]any{"s", "seq"}},
+		{"types", types, metadata.Filter{Name: "my%", WithSystem: true},
+			"(t.typrelid = 0 OR (SELECT c.relkind = 'c' FROM pg_catalog.pg_class c WHERE c.oid = t.typrelid)) AND " +
+				"NOT EXISTS(SELECT 1 FROM pg_catalog.pg_type el WHERE el.oid = t.typelem AND el.typarray = t.oid) AND t.typname LIKE $1",
+			[]any{"my%"}},
+	}
+	for _, test := range tests {
+		conn := &catalogConn{}
+		err := test.read(newCatalogClient(t, conn), test.filter)
+		assert.NoError(t, err, test.name)
+		assert.Equal(t, test.where, whereClause(conn.query), "%s %+v", test.name, test.filter)
+		assert.Equal(t, test.args, conn.args, "%s %+v", test.name, test.filter)
+	}
+}
+
+func TestFunctionsReader(t *testing.T) {
+	const sys = "n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname !~ '^pg_toast'"
+	tests := []struct {
+		columns map[string]bool
+		filter  metadata.Filter
+		where   string
+		args    []any
+		// procs and packages report whether procedures and packages are read.
+		procs, packages bool
+	}{
+		{
+			nil,
+			metadata.Filter{Parent: "pkg", Name: "f%"},
+			sys + " AND p.proname LIKE $1 AND '' LIKE $2",
+			[]any{"f%", "pkg"},
+			false, false,
+		},
+		{
+			map[string]bool{"pg_proc.prokind": true, "pg_proc.packageid": true},
+			metadata.Filter{Parent: "pkg", Name: "f%"},
+			sys + " AND p.proname LIKE $1 AND COALESCE(pkg.pkgname, '') LIKE $2",
+			[]any{"f%", "pkg"},
+			true, true,
+		},
+		{
+			map[string]bool{"pg_proc.packageid": true},
+			metadata.Filter{Schema: "s", OnlyVisible: true, WithSystem: true},
+			"pg_catalog.pg_function_is_visible(p.oid) AND n.nspname LIKE $1",
+			[]any{"s"},
+			false, true,
+		},
+	}
+	for _, test := range tests {
+		conn := &catalogConn{columns: test.columns}
+		_, err := newCatalogClient(t, conn).Functions(test.filter)
+		assert.NoError(t, err)
+		assert.Equal(t, test.where, whereClause(conn.query), "%v", test.columns)
+		assert.Equal(t, test.args, conn.args, "%v", test.columns)
+		assert.Equal(t, test.procs, strings.Contains(conn.query, "WHEN p.prokind = 'p' THEN 'proc'"), "%v", test.columns)
+		assert.Equal(t, test.packages, strings.Contains(conn.query, "JOIN pg_catalog.gs_package pkg"), "%v", test.columns)
+	}
+}
+
+func TestFunctionsReaderProbesOnce(t *testing.T) {
+	conn := &catalogConn{columns: map[string]bool{"pg_proc.prokind": true}}
+	c := newCatalogClient(t, conn)
+	for i := 0; i < 3; i++ {
+		_, err := c.Functions(metadata.Filter{})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 2, conn.probes)
+}
+
+func TestFunctionsReaderTypes(t *testing.T) {
+	tests := []struct {
+		types []string
+		args  []any
+		in    string
+	}{
+		{nil, nil, ""},
+		{[]string{"FUNCTION"}, []any{"func"}, " IN ('', $1)"},
+		{[]string{"AGGREGATE", "WINDOW"}, []any{"agg", "window"}, " IN ('', $1, $2)"},
+		{[]string{"PROCEDURE", "TRIGGER"}, []any{"proc", "trigger"}, " IN ('', $1, $2)"},
+		{[]string{"TABLE"}, nil, " IN ('')"},
+	}
+	for _, test := range tests {
+		conn := &catalogConn{}
+		_, err := newCatalogClient(t, conn).Functions(metadata.Filter{Types: test.types, WithSystem: true})
+		assert.NoError(t, err)
+		where := whereClause(conn.query)
+		if test.in == "" {
+			assert.Equal(t, "", where, "%v", test.types)
+		} else {
+			assert.True(t, strings.HasPrefix(where, "CASE WHEN p.proisagg THEN 'agg'"), "%v: %s", test.types, where)
+			assert.True(t, strings.HasSuffix(where, "ELSE 'func' END"+test.in), "%v: %s", test.types, where)
+		}
+		assert.Equal(t, test.args, conn.args, "%v", test.types)
+	}
+}
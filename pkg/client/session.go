@@ -0,0 +1,258 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"gsmate/internal/logger"
+	"gsmate/internal/orderedmap"
+	"gsmate/pkg/client/metacmd"
+
+	pq "gitee.com/opengauss/openGauss-connector-go-pq"
+	"github.com/pkg/errors"
+)
+
+var (
+	// setRE matches a SET statement, capturing the parameter name.
+	setRE = regexp.MustCompile(`(?is)^\s*SET\s+(?:SESSION\s+)?("(?:[^"]|"")+"|[a-z_][a-z0-9_.$]*)`)
+	// resetRE matches a RESET statement, capturing the parameter name.
+	resetRE = regexp.MustCompile(`(?is)^\s*RESET\s+("(?:[^"]|"")+"|[a-z_][a-z0-9_.$]*)\s*;?\s*$`)
+	// savepointRE matches a SAVEPOINT statement, capturing its name.
+	savepointRE = regexp.MustCompile(`(?is)^\s*SAVEPOINT\s+("(?:[^"]|"")+"|[a-z_][a-z0-9_$]*)`)
+	// rollbackToRE matches a ROLLBACK TO SAVEPOINT statement, capturing the
+	// savepoint name.
+	rollbackToRE = regexp.MustCompile(`(?is)^\s*ROLLBACK\s+(?:WORK\s+|TRANSACTION\s+)?TO\s+(?:SAVEPOINT\s+)?("(?:[^"]|"")+"|[a-z_][a-z0-9_$]*)`)
+)
+
+// sessionParamAliases maps the SET forms not naming their parameter to it.
+var sessionParamAliases = map[string]string{
+	"schema": "search_path",
+	"names":  "client_encoding",
+	"time":   "timezone",
+}
+
+// sessionState records the SET statements run in the session, so that
+// they can be replayed on a new connection.
+type sessionState struct {
+	sets *orderedmap.OrderedMap[string, string]
+	// pending are the SET and RESET statements, and the savepoints, of the
+	// open transaction block, applied to sets once it commits.
+	pending []pendingStmt
+}
+
+// pendingStmt is a statement of a transaction block tracked by sessionState:
+// a SET or RESET statement, or else the savepoint named savepoint.
+type pendingStmt struct {
+	q, savepoint string
+}
+
+func newSessionState() *sessionState {
+	return &sessionState{sets: orderedmap.NewOrderedMap[string, string]()}
+}
+
+// record tracks q when it is a SET or RESET of a session parameter,
+// returning the name of the parameter, "all" for RESET ALL. Within a
+// transaction block, q is kept pending until the block commits, as rolling
+// it back undoes the change.
+func (s *sessionState) record(q string, inTx bool) string {
+	name := s.apply(q, inTx)
+	if name != "" && inTx {
+		s.pending = append(s.pending, pendingStmt{q: q})
+	}
+	return name
+}
+
+// apply applies the SET or RESET statement q to sets, or only returns the
+// name of the parameter with dryRun.
+func (s *sessionState) apply(q string, dryRun bool) string {
+	if m := setRE.FindStringSubmatch(q); m != nil {
+		name := paramName(m[1])
+		switch name {
+		// not session parameters
+		case "local", "transaction", "constraints":
+			return ""
+		}
+		if !dryRun {
+			s.sets.Set(name, strings.TrimSpace(q))
+		}
+		return name
+	}
+	if m := resetRE.FindStringSubmatch(q); m != nil {
+		name := paramName(m[1])
+		if dryRun {
+			return name
+		}
+		if name == "all" {
+			s.sets.Clear()
+		} else {
+			s.sets.Delete(name)
+		}
+		return name
+	}
+	return ""
+}
+
+// trackTx updates the pending statements after the statement q with prefix
+// succeeded, moving the session from the transaction status cur to next: they
+// are applied when the block commits, dropped when it is rolled back, and
+// truncated to the savepoint a ROLLBACK TO goes back to.
+func (s *sessionState) trackTx(q, prefix string, cur, next metacmd.TxStatus) {
+	if cur == metacmd.TxIdle {
+		return
+	}
+	if next == metacmd.TxIdle {
+		// COMMIT of a failed block rolls it back
+		if cur == metacmd.TxActive && commits(prefix) {
+			for _, p := range s.pending {
+				if p.q != "" {
+					s.apply(p.q, false)
+				}
+			}
+		}
+		s.pending = nil
+		return
+	}
+	if m := savepointRE.FindStringSubmatch(q); m != nil {
+		s.pending = append(s.pending, pendingStmt{savepoint: identName(m[1])})
+		return
+	}
+	if m := rollbackToRE.FindStringSubmatch(q); m != nil {
+		name := identName(m[1])
+		for i := len(s.pending) - 1; i >= 0; i-- {
+			if s.pending[i].savepoint == name {
+				// the savepoint itself remains
+				s.pending = s.pending[:i+1]
+				return
+			}
+		}
+	}
+}
+
+// commits reports whether the statement with prefix ending a transaction
+// block commits it, the parameters set by a prepared transaction staying set.
+func commits(prefix string) bool {
+	switch firstWord(prefix) {
+	case "COMMIT", "END", "PREPARE":
+		return true
+	}
+	return false
+}
+
+// recordSession records the statement q run successfully in the session
+// state, refreshing the client encoding when q changes it.
+func (c *DBClient) recordSession(q string) {
+	switch c.session.record(q, c.txStatus != metacmd.TxIdle) {
+	case "client_encoding", "all":
+		c.initEncoding()
+	}
+}
+
+// statements returns the SET statements to replay, in execution order.
+func (s *sessionState) statements() []string {
+	return s.sets.Values()
+}
+
+// paramName normalizes the parameter name of a SET or RESET statement.
+func paramName(s string) string {
+	name := identName(s)
+	if strings.HasPrefix(s, `"`) {
+		return name
+	}
+	if alias, ok := sessionParamAliases[name]; ok {
+		return alias
+	}
+	return name
+}
+
+// identName normalizes an identifier, folding it to lower case unless it is
+// double quoted.
+func identName(s string) string {
+	if strings.HasPrefix(s, `"`) {
+		return strings.ReplaceAll(s[1:len(s)-1], `""`, `"`)
+	}
+	return strings.ToLower(s)
+}
+
+// isConnError reports whether err means the connection to the server was
+// lost.
+func isConnError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr *net.OpError
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		// admin_shutdown, crash_shutdown, cannot_connect_now
+		case "57P01", "57P02", "57P03":
+			return true
+		}
+		// connection_exception
+		return pqErr.Code.Class() == "08"
+	}
+	return false
+}
+
+// handleConnError reconnects after err when it means the connection was
+// lost, in interactive mode only as scripts must not silently go on with a
+// new session.
+func (c *DBClient) handleConnError(err error) {
+	if err == nil || !c.interactive || !isConnError(err) {
+		return
+	}
+	logger.Warn("The connection to the server was lost. Attempting reset...")
+	if err := c.reconnect(); err != nil {
+		logger.Error("reset failed: %s", err)
+		return
+	}
+	logger.Warn("Reset succeeded, connected to %s (%s).", c.node.addr, c.node.role())
+}
+
+// reconnect replaces the lost connection with a new one to the configured
+// hosts, restoring the session parameters set so far.
+func (c *DBClient) reconnect() error {
+	db, n, err := connect(c.cfg, c.handleNotice)
+	if err != nil {
+		return err
+	}
+	inTx := c.txStatus != metacmd.TxIdle
+	if err := c.attach(db, n); err != nil {
+		return err
+	}
+	// the changes of the lost transaction are gone with it
+	c.session.pending = nil
+	if inTx {
+		logger.Warn("The open transaction was lost, its changes were rolled back.")
+	}
+	for _, q := range c.session.statements() {
+		if _, err := c.DB().Exec(q); err != nil {
+			logger.Warn("could not restore %q: %s", q, err)
+		}
+	}
+	c.refreshPromptPrefix()
+	return c.initServerInfo()
+}
@@ -15,6 +15,9 @@
 package client
 
 const (
-	queryDBVersion = "SELECT SUBSTRING(version() FROM '\\(([^)]+)\\)') AS version"
-	queryServerPID = "SELECT pg_backend_pid()"
+	queryDBVersion      = "SELECT SUBSTRING(version() FROM '\\(([^)]+)\\)') AS version"
+	queryServerPID      = "SELECT pg_backend_pid()"
+	queryCancelBackend  = "SELECT pg_catalog.pg_cancel_backend($1)"
+	queryInRecovery     = "SELECT pg_catalog.pg_is_in_recovery()"
+	queryClientEncoding = "SHOW client_encoding"
 )
@@ -14,7 +14,11 @@
 
 package client
 
-import "github.com/vimiix/go-prompt"
+import (
+	"gsmate/pkg/client/metacmd"
+
+	"github.com/vimiix/go-prompt"
+)
 
 // these objects can be create/alter/drop
 var operableObj = []string{
@@ -168,8 +172,13 @@ func getStartSQLCmdSuggests() []prompt.Suggest {
 	return r
 }
 
-var backslashCommands = []prompt.Suggest{
-	{Text: `\!`, Description: "execute command in shell or start interactive shell"},
-	{Text: `\?`, Description: "show help on commands"},
-	{Text: `\copyright`, Description: "show gsmater copyright information"},
+func getBackslashCmdSuggests() []prompt.Suggest {
+	var r []prompt.Suggest
+	for _, cmd := range metacmd.Cmds() {
+		r = append(r, prompt.Suggest{Text: `\` + cmd.Name, Description: cmd.Desc})
+		for _, alias := range cmd.Aliases {
+			r = append(r, prompt.Suggest{Text: `\` + alias, Description: cmd.Desc})
+		}
+	}
+	return r
 }
@@ -0,0 +1,190 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// returningRE matches the RETURNING clause of a data modifying statement.
+var returningRE = regexp.MustCompile(`(?i)\bRETURNING\b`)
+
+// returnsRows reports whether the statement q, with prefix as found by
+// FindPrefix, produces rows to render rather than only a command tag.
+func returnsRows(prefix, q string) bool {
+	switch firstWord(prefix) {
+	// no prefix, eg a parenthesized query
+	case "", "SELECT", "WITH", "VALUES", "TABLE", "SHOW", "EXPLAIN", "FETCH", "CALL", "EXECUTE":
+		return true
+	case "INSERT", "UPDATE", "DELETE", "MERGE":
+		return returningRE.MatchString(stripQuoted(q))
+	}
+	return false
+}
+
+// stripQuoted returns q with its string literals, quoted identifiers and
+// comments replaced by a space, so that keywords are only matched in the
+// statement itself.
+func stripQuoted(q string) string {
+	r := []rune(q)
+	s := make([]rune, 0, len(r))
+	for i, end := 0, len(r); i < end; i++ {
+		c, next := r[i], grab(r, i+1, end)
+		switch {
+		case c == '\'' || c == '"':
+			i, _ = readString(r, i+1, end, c, "")
+		case c == '$' && (next == '$' || next == '_' || unicode.IsLetter(next)):
+			id, pos, ok := readDollarAndTag(r, i, end)
+			if !ok {
+				s = append(s, c)
+				continue
+			}
+			i, _ = readString(r, pos+1, end, '$', id)
+		case c == '-' && next == '-':
+			i, _ = findRune(r, i, end, '\n')
+		case c == '/' && next == '*':
+			i, _ = readMultilineComment(r, i+2, end)
+		default:
+			s = append(s, c)
+			continue
+		}
+		s = append(s, ' ')
+	}
+	return string(s)
+}
+
+// isDML reports whether the statement with prefix modifies rows, its
+// command tag then carrying the row count.
+func isDML(prefix string) bool {
+	switch firstWord(prefix) {
+	case "INSERT", "UPDATE", "DELETE", "MERGE":
+		return true
+	}
+	return false
+}
+
+func firstWord(prefix string) string {
+	return strings.SplitN(prefix, " ", 2)[0]
+}
+
+// commandTags are the command tags of the statements tagged differently
+// from their first word.
+var commandTags = map[string]string{
+	"ABORT":    "ROLLBACK",
+	"END":      "COMMIT",
+	"LOCK":     "LOCK TABLE",
+	"TRUNCATE": "TRUNCATE TABLE",
+}
+
+// objectModifiers are the words of CREATE, ALTER and DROP statements that
+// precede the object type but are not part of the command tag.
+var objectModifiers = map[string]bool{
+	"OR":         true,
+	"REPLACE":    true,
+	"UNIQUE":     true,
+	"GLOBAL":     true,
+	"LOCAL":      true,
+	"TEMP":       true,
+	"TEMPORARY":  true,
+	"UNLOGGED":   true,
+	"TRUSTED":    true,
+	"PROCEDURAL": true,
+	"RECURSIVE":  true,
+}
+
+// objectTypes are the object types made of several words.
+var objectTypes = []string{
+	"ACCESS METHOD",
+	"APP WORKLOAD GROUP",
+	"DATA SOURCE",
+	"DEFAULT PRIVILEGES",
+	"EVENT TRIGGER",
+	"FOREIGN DATA WRAPPER",
+	"FOREIGN TABLE",
+	"LARGE SEQUENCE",
+	"MATERIALIZED VIEW",
+	"NODE GROUP",
+	"OPERATOR CLASS",
+	"OPERATOR FAMILY",
+	"PACKAGE BODY",
+	"RESOURCE POOL",
+	"ROW LEVEL SECURITY POLICY",
+	"TEXT SEARCH CONFIGURATION",
+	"TEXT SEARCH DICTIONARY",
+	"TEXT SEARCH PARSER",
+	"TEXT SEARCH TEMPLATE",
+	"USER MAPPING",
+	"WORKLOAD GROUP",
+}
+
+// commandTag returns the psql style command tag of the statement with
+// prefix, which affected rows rows, eg "INSERT 0 3" or "CREATE TABLE".
+func commandTag(prefix string, rows int64) string {
+	words := strings.Fields(prefix)
+	if len(words) == 0 {
+		return ""
+	}
+	second := func(s string) bool {
+		return len(words) > 1 && words[1] == s
+	}
+	switch words[0] {
+	case "INSERT":
+		return fmt.Sprintf("INSERT 0 %d", rows)
+	case "UPDATE", "DELETE", "MERGE", "SELECT", "MOVE", "FETCH", "COPY":
+		return fmt.Sprintf("%s %d", words[0], rows)
+	case "CREATE", "ALTER", "DROP":
+		return objectTag(words)
+	case "START", "PREPARE":
+		if second("TRANSACTION") {
+			return words[0] + " TRANSACTION"
+		}
+	case "COMMIT", "ROLLBACK":
+		if second("PREPARED") {
+			return words[0] + " PREPARED"
+		}
+	case "DISCARD":
+		if len(words) > 1 {
+			return "DISCARD " + words[1]
+		}
+	case "REFRESH":
+		return "REFRESH MATERIALIZED VIEW"
+	}
+	if tag, ok := commandTags[words[0]]; ok {
+		return tag
+	}
+	return words[0]
+}
+
+// objectTag returns the command tag of a CREATE, ALTER or DROP statement
+// made of words.
+func objectTag(words []string) string {
+	i := 1
+	for i < len(words) && objectModifiers[words[i]] {
+		i++
+	}
+	if i == len(words) {
+		return words[0]
+	}
+	rest := strings.Join(words[i:], " ") + " "
+	for _, typ := range objectTypes {
+		if strings.HasPrefix(rest, typ+" ") {
+			return words[0] + " " + typ
+		}
+	}
+	return words[0] + " " + words[i]
+}
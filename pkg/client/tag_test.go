@@ -0,0 +1,85 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import "testing"
+
+func TestReturnsRows(t *testing.T) {
+	tests := []struct {
+		q   string
+		exp bool
+	}{
+		{"select 1", true},
+		{"(select 1)", true},
+		{"with t as (select 1) select * from t", true},
+		{"show search_path", true},
+		{"explain select 1", true},
+		{"insert into t values (1)", false},
+		{"insert into t values (1) returning id", true},
+		{"update t set a = 1", false},
+		{"delete from t returning *", true},
+		{"insert into t values ('returning')", false},
+		{"insert into t values ('it''s', 'returning') returning id", true},
+		{`update t set "returning" = 1`, false},
+		{"insert into t values ($$returning$$)", false},
+		{"insert into t values ($x$ $$ returning $x$) returning *", true},
+		{"delete from t -- returning *", false},
+		{"delete from t /* returning * */ where a = $1", false},
+		{"create table t (a int)", false},
+		{"set search_path to public", false},
+		{"begin", false},
+	}
+	for i, test := range tests {
+		if b := returnsRows(FindPrefix(test.q), test.q); b != test.exp {
+			t.Errorf("test %d %q expected %t, got: %t", i, test.q, test.exp, b)
+		}
+	}
+}
+
+func TestCommandTag(t *testing.T) {
+	tests := []struct {
+		q    string
+		rows int64
+		exp  string
+	}{
+		{"insert into t values (1), (2), (3)", 3, "INSERT 0 3"},
+		{"update t set a = 1", 12, "UPDATE 12"},
+		{"delete from t", 0, "DELETE 0"},
+		{"create table t (a int)", 0, "CREATE TABLE"},
+		{"create table if not exists t (a int)", 0, "CREATE TABLE"},
+		{"create or replace function f() returns int", 0, "CREATE FUNCTION"},
+		{"create unique index i on t (a)", 0, "CREATE INDEX"},
+		{"create global temporary table t (a int)", 0, "CREATE TABLE"},
+		{"create materialized view v as select 1", 0, "CREATE MATERIALIZED VIEW"},
+		{"drop foreign table if exists t", 0, "DROP FOREIGN TABLE"},
+		{"alter default privileges grant select on tables to u", 0, "ALTER DEFAULT PRIVILEGES"},
+		{"create text search configuration c (copy = simple)", 0, "CREATE TEXT SEARCH CONFIGURATION"},
+		{"start transaction", 0, "START TRANSACTION"},
+		{"end", 0, "COMMIT"},
+		{"abort", 0, "ROLLBACK"},
+		{"rollback to savepoint s", 0, "ROLLBACK"},
+		{"commit prepared 'tx'", 0, "COMMIT PREPARED"},
+		{"truncate t", 0, "TRUNCATE TABLE"},
+		{"set search_path to public", 0, "SET"},
+		{"grant select on t to u", 0, "GRANT"},
+		{"discard all", 0, "DISCARD ALL"},
+		{"", 0, ""},
+	}
+	for i, test := range tests {
+		if s := commandTag(FindPrefix(test.q), test.rows); s != test.exp {
+			t.Errorf("test %d %q expected %q, got: %q", i, test.q, test.exp, s)
+		}
+	}
+}
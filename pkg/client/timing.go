@@ -0,0 +1,101 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// countingRows counts the rows read from the embedded rows, timing their
+// fetching apart from the rendering reading them.
+type countingRows struct {
+	*sql.Rows
+	n int64
+	// start is when the statement was sent.
+	start time.Time
+	// firstRow is the time from start to the first row, or to the end of
+	// an empty result.
+	firstRow time.Duration
+	// first is the time spent in the first call of Next.
+	first time.Duration
+	// fetch is the time spent in the following calls of Next.
+	fetch time.Duration
+}
+
+func (r *countingRows) Next() bool {
+	t := time.Now()
+	ok := r.Rows.Next()
+	d := time.Since(t)
+	if r.firstRow == 0 {
+		r.firstRow, r.first = time.Since(r.start), d
+	} else {
+		r.fetch += d
+	}
+	if ok {
+		r.n++
+	}
+	return ok
+}
+
+// queryTiming is the elapsed time of a statement.
+type queryTiming struct {
+	total time.Duration
+	// firstRow, fetch and render break total down for statements
+	// returning rows.
+	firstRow, fetch, render time.Duration
+	rows                    bool
+}
+
+// timing returns the timing of the statement rendered from r in render,
+// ending at end.
+func (r *countingRows) timing(render time.Duration, end time.Time) queryTiming {
+	return queryTiming{
+		total:    end.Sub(r.start),
+		firstRow: r.firstRow,
+		fetch:    r.fetch,
+		render:   render - r.first - r.fetch,
+		rows:     true,
+	}
+}
+
+// String satisfies the fmt.Stringer interface.
+func (t queryTiming) String() string {
+	s := "Time: " + formatMillis(t.total)
+	if t.rows {
+		s += fmt.Sprintf(" (first row: %s, fetch: %s, render: %s)",
+			formatMillis(t.firstRow), formatMillis(t.fetch), formatMillis(t.render))
+	}
+	return s
+}
+
+// formatMillis formats d in milliseconds, as psql does.
+func formatMillis(d time.Duration) string {
+	return fmt.Sprintf("%.3f ms", millis(d))
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// recordTiming keeps the duration of the last statement in the
+// LAST_DURATION variable, in milliseconds, and prints it when timing is on.
+func (c *DBClient) recordTiming(t queryTiming) {
+	c.setVar("LAST_DURATION", fmt.Sprintf("%.3f", millis(t.total)))
+	if c.cfg.Timing {
+		fmt.Fprintln(c.Out(), t)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueryTiming(t *testing.T) {
+	tests := []struct {
+		t   queryTiming
+		exp string
+	}{
+		{queryTiming{total: 1500 * time.Microsecond}, "Time: 1.500 ms"},
+		{
+			queryTiming{
+				total:    12 * time.Millisecond,
+				firstRow: 9 * time.Millisecond,
+				fetch:    2 * time.Millisecond,
+				render:   time.Millisecond,
+				rows:     true,
+			},
+			"Time: 12.000 ms (first row: 9.000 ms, fetch: 2.000 ms, render: 1.000 ms)",
+		},
+	}
+	for i, test := range tests {
+		if s := test.t.String(); s != test.exp {
+			t.Errorf("test %d expected %q, got: %q", i, test.exp, s)
+		}
+	}
+}
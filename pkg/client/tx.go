@@ -0,0 +1,239 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"gsmate/internal/logger"
+	"gsmate/pkg/client/metacmd"
+)
+
+// sessionConn is the single connection of the session. Pinning it makes
+// consecutive statements, such as those of a transaction block, run on the
+// same server session instead of any connection of the pool.
+type sessionConn struct {
+	*sql.Conn
+}
+
+func (c sessionConn) Exec(q string, args ...interface{}) (sql.Result, error) {
+	return c.ExecContext(context.Background(), q, args...)
+}
+
+func (c sessionConn) Query(q string, args ...interface{}) (*sql.Rows, error) {
+	return c.QueryContext(context.Background(), q, args...)
+}
+
+func (c sessionConn) QueryRow(q string, args ...interface{}) *sql.Row {
+	return c.QueryRowContext(context.Background(), q, args...)
+}
+
+func (c sessionConn) Prepare(q string) (*sql.Stmt, error) {
+	return c.PrepareContext(context.Background(), q)
+}
+
+// attach makes db, connected to n, the database of the client, pinning its
+// session connection and closing the previous one.
+func (c *DBClient) attach(db *sql.DB, n node) error {
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		_ = db.Close()
+		return err
+	}
+	var pid int64
+	if err := conn.QueryRowContext(context.Background(), queryServerPID).Scan(&pid); err != nil {
+		_ = conn.Close()
+		_ = db.Close()
+		return err
+	}
+	c.detach()
+	c.db, c.conn, c.node, c.pid = db, conn, n, pid
+	c.txStatus, c.catalogColumns = metacmd.TxIdle, nil
+	return nil
+}
+
+// detach closes the connection of the client, if any.
+func (c *DBClient) detach() {
+	if c.conn != nil {
+		_ = c.conn.Close()
+	}
+	if c.db != nil {
+		_ = c.db.Close()
+	}
+}
+
+// TxStatus satisfies the metacmd.Handler interface.
+func (c *DBClient) TxStatus() metacmd.TxStatus {
+	return c.txStatus
+}
+
+// nextTxStatus returns the transaction status following the execution of
+// the statement with prefix, as found by FindPrefix, in status cur. err is
+// the error of the execution.
+func nextTxStatus(cur metacmd.TxStatus, prefix string, err error) metacmd.TxStatus {
+	if err != nil {
+		if cur == metacmd.TxIdle {
+			return cur
+		}
+		return metacmd.TxFailed
+	}
+	word := func(i int) string {
+		if words := strings.Fields(prefix); i < len(words) {
+			return words[i]
+		}
+		return ""
+	}
+	switch word(0) {
+	case "BEGIN":
+		return metacmd.TxActive
+	case "START":
+		if word(1) == "TRANSACTION" {
+			return metacmd.TxActive
+		}
+	case "COMMIT", "ROLLBACK":
+		switch word(1) {
+		case "PREPARED":
+			// runs outside of transaction blocks
+			return cur
+		case "TO":
+			// ROLLBACK TO SAVEPOINT recovers a failed transaction
+			return metacmd.TxActive
+		}
+		return metacmd.TxIdle
+	case "END", "ABORT":
+		return metacmd.TxIdle
+	case "PREPARE":
+		if word(1) == "TRANSACTION" {
+			return metacmd.TxIdle
+		}
+	}
+	return cur
+}
+
+// savepointName is the savepoint wrapping statements for on_error_rollback.
+const savepointName = "gsmate_temporary_savepoint"
+
+// noImplicitBegin reports whether the statement with prefix must not be
+// preceded by an implicit BEGIN when autocommit is off, as it either
+// controls the transaction itself or cannot run in a transaction block.
+func noImplicitBegin(prefix string) bool {
+	words := strings.Fields(prefix)
+	if len(words) == 0 {
+		return true
+	}
+	word := func(i int) string {
+		if i < len(words) {
+			return words[i]
+		}
+		return ""
+	}
+	switch word(0) {
+	case "BEGIN", "START", "COMMIT", "END", "ROLLBACK", "ABORT", "VACUUM":
+		return true
+	case "PREPARE":
+		return word(1) == "TRANSACTION"
+	case "CLUSTER":
+		// CLUSTER with arguments runs in transaction blocks
+		return word(1) == ""
+	case "CREATE", "DROP":
+		switch word(1) {
+		case "DATABASE", "TABLESPACE":
+			return true
+		case "INDEX":
+			return word(2) == "CONCURRENTLY"
+		case "UNIQUE":
+			return word(2) == "INDEX" && word(3) == "CONCURRENTLY"
+		}
+	case "REINDEX":
+		return word(1) == "DATABASE" || word(1) == "SYSTEM"
+	case "DISCARD":
+		return word(1) == "ALL"
+	}
+	return false
+}
+
+// keepsSavepoint reports whether the statement with prefix handles
+// savepoints itself, so that the on_error_rollback savepoint must not be
+// released after it.
+func keepsSavepoint(prefix string) bool {
+	switch strings.SplitN(prefix, " ", 2)[0] {
+	case "SAVEPOINT", "RELEASE", "ROLLBACK":
+		return true
+	}
+	return false
+}
+
+// rollbackOnError reports whether on_error_rollback applies to the
+// statements being executed.
+func (c *DBClient) rollbackOnError() bool {
+	switch c.cfg.OnErrorRollback {
+	case "on":
+		return true
+	case "interactive":
+		return c.interactive
+	}
+	return false
+}
+
+// runInTx runs the statement q with prefix through run, implicitly beginning
+// a transaction block first when autocommit is off, and wrapping it in a
+// savepoint when on_error_rollback applies, so that its failure does not
+// abort the whole transaction.
+func (c *DBClient) runInTx(q, prefix string, run func(q, prefix string) error) error {
+	if !c.cfg.Autocommit && c.txStatus == metacmd.TxIdle && !noImplicitBegin(prefix) {
+		if _, err := c.DB().Exec("BEGIN"); err != nil {
+			c.handleConnError(err)
+			return err
+		}
+		c.txStatus = metacmd.TxActive
+	}
+	savepoint := c.txStatus == metacmd.TxActive && c.rollbackOnError()
+	if savepoint {
+		if _, err := c.DB().Exec("SAVEPOINT " + savepointName); err != nil {
+			c.handleConnError(err)
+			return err
+		}
+	}
+
+	err := run(q, prefix)
+	// a lost connection has already been replaced, along with its status
+	if isConnError(err) {
+		return err
+	}
+	cur := c.txStatus
+	c.txStatus = nextTxStatus(cur, prefix, err)
+	if err == nil {
+		c.session.trackTx(q, prefix, cur, c.txStatus)
+	}
+	if !savepoint {
+		return err
+	}
+	switch {
+	case c.txStatus == metacmd.TxFailed:
+		if _, rerr := c.DB().Exec("ROLLBACK TO SAVEPOINT " + savepointName); rerr != nil {
+			c.handleConnError(rerr)
+			return err
+		}
+		c.txStatus = metacmd.TxActive
+	case c.txStatus == metacmd.TxActive && !keepsSavepoint(prefix):
+		if _, rerr := c.DB().Exec("RELEASE SAVEPOINT " + savepointName); rerr != nil {
+			c.handleConnError(rerr)
+			logger.Warn("could not release savepoint: %s", rerr)
+		}
+	}
+	return err
+}
@@ -0,0 +1,238 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gsmate/config"
+	"gsmate/internal/utils"
+
+	pq "gitee.com/opengauss/openGauss-connector-go-pq"
+	"github.com/pkg/errors"
+)
+
+// varNameRE matches a valid variable name.
+var varNameRE = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// specialVar is a variable backed by a config setting, changing the
+// behavior of the client.
+type specialVar struct {
+	get func(*config.Config) string
+	set func(*config.Config, string) error
+}
+
+// specialVars are the special variables by name.
+var specialVars = map[string]specialVar{
+	"AUTOCOMMIT": boolVar(func(c *config.Config) *bool { return &c.Autocommit }),
+	"ON_ERROR_ROLLBACK": {
+		get: func(c *config.Config) string { return c.OnErrorRollback },
+		set: func(c *config.Config, v string) error {
+			if b, err := utils.ParseBool(v); err == nil {
+				v = onOff(b)
+			}
+			if err := config.CheckOnErrorRollback(v); err != nil {
+				return err
+			}
+			c.OnErrorRollback = v
+			return nil
+		},
+	},
+	"ON_ERROR_STOP": boolVar(func(c *config.Config) *bool { return &c.OnErrorStop }),
+	"TIMING":        boolVar(func(c *config.Config) *bool { return &c.Timing }),
+	"VERBOSITY": {
+		get: func(c *config.Config) string { return c.Verbosity },
+		set: func(c *config.Config, v string) error {
+			if err := config.CheckVerbosity(v); err != nil {
+				return err
+			}
+			c.Verbosity = v
+			return nil
+		},
+	},
+}
+
+// boolVar returns a special variable backed by the boolean setting field
+// returns.
+func boolVar(field func(*config.Config) *bool) specialVar {
+	return specialVar{
+		get: func(c *config.Config) string { return onOff(*field(c)) },
+		set: func(c *config.Config, v string) error {
+			b, err := utils.ParseBool(v)
+			if err != nil {
+				return err
+			}
+			*field(c) = b
+			return nil
+		},
+	}
+}
+
+// keepSpecialVars sets the special variables of cfg to their values in the
+// configuration of the session, which survive switching profiles.
+func keepSpecialVars(cfg, session *config.Config) {
+	for _, v := range specialVars {
+		_ = v.set(cfg, v.get(session))
+	}
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// connVars returns the variables describing the connection, which are
+// read-only.
+func (c *DBClient) connVars() map[string]string {
+	return map[string]string{
+		"DBNAME":   c.cfg.DBName,
+		"USER":     c.cfg.Username,
+		"HOST":     c.node.addr.Host,
+		"PORT":     strconv.Itoa(c.node.addr.Port),
+		"ENCODING": c.encoding,
+	}
+}
+
+// SetVar satisfies the metacmd.Handler interface.
+func (c *DBClient) SetVar(name, value string) error {
+	if !varNameRE.MatchString(name) {
+		return errors.Errorf("invalid variable name: %q", name)
+	}
+	if v, ok := specialVars[name]; ok {
+		if err := v.set(c.cfg, value); err != nil {
+			return errors.Wrapf(err, "%s", name)
+		}
+		return nil
+	}
+	if _, ok := c.connVars()[name]; ok {
+		return errors.Errorf("%s is read-only", name)
+	}
+	c.setVar(name, value)
+	return nil
+}
+
+// UnsetVar satisfies the metacmd.Handler interface.
+func (c *DBClient) UnsetVar(name string) error {
+	if _, ok := specialVars[name]; ok {
+		return errors.Errorf("%s cannot be unset", name)
+	}
+	if _, ok := c.connVars()[name]; ok {
+		return errors.Errorf("%s is read-only", name)
+	}
+	delete(c.vars, name)
+	return nil
+}
+
+// setVar sets the plain variable name, as done for the variables gsmate
+// maintains, such as ROW_COUNT.
+func (c *DBClient) setVar(name, value string) {
+	if c.vars == nil {
+		c.vars = map[string]string{}
+	}
+	c.vars[name] = value
+}
+
+// Vars satisfies the metacmd.Handler interface.
+func (c *DBClient) Vars() map[string]string {
+	vars := c.connVars()
+	for name, v := range c.vars {
+		vars[name] = v
+	}
+	for name, v := range specialVars {
+		vars[name] = v.get(c.cfg)
+	}
+	return vars
+}
+
+// getVar returns the value of the variable name.
+func (c *DBClient) getVar(name string) (string, bool) {
+	if v, ok := specialVars[name]; ok {
+		return v.get(c.cfg), true
+	}
+	if v, ok := c.connVars()[name]; ok {
+		return v, true
+	}
+	v, ok := c.vars[name]
+	return v, ok
+}
+
+// unquote resolves the quoted string s, or the variable s interpolated as
+// :name, :'name' or :"name" when isVar is set. The value of a variable is
+// quoted as a literal or as an identifier in the latter forms. Variables not
+// set fall back to the config keys.
+func (c *DBClient) unquote(s string, isVar bool) (bool, string, error) {
+	if !isVar || s == "" {
+		return Unquote(s, isVar)
+	}
+	name, quote := s, s[0]
+	if quote == '\'' || quote == '"' {
+		var err error
+		if name, err = Dequote(s, quote); err != nil {
+			return false, "", err
+		}
+	}
+	v, ok := c.getVar(name)
+	if !ok {
+		return Unquote(s, isVar)
+	}
+	switch quote {
+	case '\'':
+		return true, escapeLiteral(v), nil
+	case '"':
+		return true, quoteIdent(v), nil
+	}
+	return true, v, nil
+}
+
+// escapeLiteral quotes s as an SQL string literal, using the escape string
+// syntax when s holds backslashes so that they are kept whatever the value
+// of standard_conforming_strings.
+func escapeLiteral(s string) string {
+	q := quoteLiteral(s)
+	if strings.Contains(s, `\`) {
+		return "E" + strings.ReplaceAll(q, `\`, `\\`)
+	}
+	return q
+}
+
+// recordResult sets the variables describing the outcome of the last
+// statement, which affected or returned rows rows or failed with err.
+func (c *DBClient) recordResult(prefix string, rows int64, err error) {
+	if err == nil {
+		c.setVar("ERROR", "false")
+		c.setVar("SQLSTATE", "00000")
+		c.setVar("ROW_COUNT", strconv.FormatInt(rows, 10))
+		if firstWord(prefix) == "INSERT" {
+			// the driver does not report the oid of inserted rows, which
+			// is only set for tables created WITH OIDS anyway
+			c.setVar("LASTOID", "0")
+		}
+		return
+	}
+	sqlstate, message := "", err.Error()
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		sqlstate, message = string(pqErr.Code), pqErr.Message
+	}
+	c.setVar("ERROR", "true")
+	c.setVar("SQLSTATE", sqlstate)
+	c.setVar("ROW_COUNT", "0")
+	c.setVar("LAST_ERROR_MESSAGE", message)
+	c.setVar("LAST_ERROR_SQLSTATE", sqlstate)
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"gsmate/config"
+	"gsmate/pkg/client/metacmd"
+
+	pq "gitee.com/opengauss/openGauss-connector-go-pq"
+	"github.com/pkg/errors"
+	"golang.org/x/term"
+)
+
+const (
+	// clearScreen moves the cursor home and clears the terminal.
+	clearScreen = "\x1b[H\x1b[2J"
+	// reverseOn and reverseOff delimit the changed characters under \watch h.
+	reverseOn  = "\x1b[7m"
+	reverseOff = "\x1b[27m"
+)
+
+// nopCloser makes a writer an io.WriteCloser.
+type nopCloser struct {
+	io.Writer
+}
+
+// Close satisfies the io.WriteCloser interface.
+func (nopCloser) Close() error {
+	return nil
+}
+
+// watch runs q with prefix as \watch: every interval until count runs are
+// done, forever when 0, or until interrupted with Ctrl-C or failing. Each
+// result is rendered below a header with the time and the run number,
+// redrawing the screen of a terminal, and with the characters changed since
+// the previous run highlighted when asked.
+func (c *DBClient) watch(q, prefix string, opt metacmd.Option) error {
+	count, _ := strconv.Atoi(opt.Params["count"])
+	highlight := opt.Params["highlight"] == "on"
+	out := c.Out()
+	redraw := out == io.Writer(os.Stdout) && term.IsTerminal(int(os.Stdout.Fd()))
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	defer signal.Stop(sig)
+
+	var prev []string
+	for i := 1; count == 0 || i <= count; i++ {
+		var buf bytes.Buffer
+		prevOut := c.out
+		c.out = nopCloser{&buf}
+		err := c.runInTx(q, prefix, c.doQuery)
+		c.out = prevOut
+		if err != nil {
+			if watchInterrupted(err, sig) {
+				return nil
+			}
+			// the error is reported, keep it on screen
+			c.recordResult(prefix, 0, err)
+			c.reportError(err, q)
+			return err
+		}
+
+		lines := strings.SplitAfter(buf.String(), "\n")
+		shown := lines
+		if highlight && prev != nil {
+			shown = highlightChanges(prev, lines)
+		}
+		prev = lines
+		if redraw {
+			fmt.Fprint(out, clearScreen)
+		}
+		fmt.Fprintln(out, watchHeader(time.Now(), opt.Watch, i, count))
+		fmt.Fprintln(out)
+		fmt.Fprint(out, strings.Join(shown, ""))
+
+		if i == count {
+			break
+		}
+		select {
+		case <-sig:
+			return nil
+		case <-time.After(opt.Watch):
+		}
+	}
+	return nil
+}
+
+// watchInterrupted reports whether err is the cancellation of the query by
+// a Ctrl-C received on sig, which stops \watch without an error.
+func watchInterrupted(err error, sig <-chan os.Signal) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != errQueryCanceled {
+		return false
+	}
+	select {
+	case <-sig:
+		return true
+	default:
+		return false
+	}
+}
+
+// watchHeader returns the header of the \watch run i of count at t, with the
+// table title if any.
+func watchHeader(t time.Time, interval time.Duration, i, count int) string {
+	run := strconv.Itoa(i)
+	if count != 0 {
+		run += " of " + strconv.Itoa(count)
+	}
+	header := fmt.Sprintf("%s (every %s, run %s)", t.Format("Mon Jan _2 15:04:05 2006"), interval, run)
+	if title := config.GetPrintConfig()["title"]; title != "" {
+		header = title + "  " + header
+	}
+	return header
+}
+
+// highlightChanges marks the characters of lines differing from the ones at
+// the same position in prev in reverse video, as watch -d does.
+func highlightChanges(prev, lines []string) []string {
+	marked := make([]string, len(lines))
+	for i, line := range lines {
+		var old []rune
+		if i < len(prev) {
+			old = []rune(prev[i])
+		}
+		var sb strings.Builder
+		changed := false
+		for j, r := range []rune(line) {
+			diff := r != '\n' && (j >= len(old) || old[j] != r)
+			if diff != changed {
+				if diff {
+					sb.WriteString(reverseOn)
+				} else {
+					sb.WriteString(reverseOff)
+				}
+				changed = diff
+			}
+			sb.WriteRune(r)
+		}
+		if changed {
+			sb.WriteString(reverseOff)
+		}
+		marked[i] = sb.String()
+	}
+	return marked
+}
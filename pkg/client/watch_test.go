@@ -0,0 +1,84 @@
+// Copyright 2024 Qian Yao
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	pq "gitee.com/opengauss/openGauss-connector-go-pq"
+	"github.com/pkg/errors"
+)
+
+func TestHighlightChanges(t *testing.T) {
+	prev := []string{" lag \n", "-----\n", "  10 \n"}
+	lines := []string{" lag \n", "-----\n", "  12 \n", "  7  \n"}
+	exp := []string{
+		" lag \n",
+		"-----\n",
+		"  1" + reverseOn + "2" + reverseOff + " \n",
+		reverseOn + "  7  " + reverseOff + "\n",
+	}
+	got := highlightChanges(prev, lines)
+	if len(got) != len(exp) {
+		t.Fatalf("expected %d lines, got %d", len(exp), len(got))
+	}
+	for i := range exp {
+		if got[i] != exp[i] {
+			t.Errorf("line %d: expected %q, got %q", i, exp[i], got[i])
+		}
+	}
+}
+
+func TestWatchHeader(t *testing.T) {
+	at := time.Date(2024, 3, 5, 14, 7, 9, 0, time.UTC)
+	tests := []struct {
+		i, count int
+		exp      string
+	}{
+		{1, 0, "Tue Mar  5 14:07:09 2024 (every 2s, run 1)"},
+		{3, 10, "Tue Mar  5 14:07:09 2024 (every 2s, run 3 of 10)"},
+	}
+	for _, test := range tests {
+		if s := watchHeader(at, 2*time.Second, test.i, test.count); s != test.exp {
+			t.Errorf("expected %q, got %q", test.exp, s)
+		}
+	}
+}
+
+func TestWatchInterrupted(t *testing.T) {
+	canceled := errors.Wrap(&pq.Error{Code: errQueryCanceled}, "watch")
+	tests := []struct {
+		err         error
+		interrupted bool
+		exp         bool
+	}{
+		{canceled, true, true},
+		// canceled by statement_timeout or another session
+		{canceled, false, false},
+		{&pq.Error{Code: "42P01"}, true, false},
+		{errors.New("connection reset"), true, false},
+	}
+	for i, test := range tests {
+		sig := make(chan os.Signal, 1)
+		if test.interrupted {
+			sig <- os.Interrupt
+		}
+		if b := watchInterrupted(test.err, sig); b != test.exp {
+			t.Errorf("test %d expected %t, got %t", i, test.exp, b)
+		}
+	}
+}
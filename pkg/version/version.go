@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"runtime"
 	"strings"
+	"time"
 )
 
 var (
@@ -41,3 +42,14 @@ os/arch:   %s/%s`,
 
 	return strings.TrimSpace(versionDetail)
 }
+
+// Copyright returns the copyright notice, with the year range extended to the
+// current year.
+func Copyright() string {
+	yearRange := "2024"
+	nowYear := time.Now().Year()
+	if nowYear > 2024 {
+		yearRange = fmt.Sprintf("2024-%d", nowYear)
+	}
+	return fmt.Sprintf("Copyright (C) %s Vimiix", yearRange)
+}